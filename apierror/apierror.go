@@ -0,0 +1,32 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// StatusError is an error of unexpected response status, the body is empty if it is not read.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected status code=%d", e.Code)
+	}
+
+	return fmt.Sprintf("unexpected status code=%v: %v", e.Code, e.Body)
+}
+
+// New returns an error of unexpected response status joined with the base error.
+func New(base error, status int, body io.Reader) error {
+	bodyBytes, err := io.ReadAll(body)
+	if err != nil {
+		return errors.Join(base, &StatusError{Code: status}, err)
+	}
+
+	return errors.Join(base, &StatusError{Code: status, Body: string(bodyBytes)})
+}
@@ -0,0 +1,48 @@
+package apierror
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// failedReader is a reader which always fails.
+type failedReader struct{}
+
+func (failedReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestNew(t *testing.T) {
+	base := errors.New("base")
+
+	testCases := []struct {
+		name     string
+		body     io.Reader
+		expected string
+	}{
+		{name: "body", body: strings.NewReader("quota"), expected: "unexpected status code=429: quota"},
+		{name: "empty", body: strings.NewReader(""), expected: "unexpected status code=429"},
+		{name: "failed", body: failedReader{}, expected: "unexpected status code=429"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := New(base, 429, tc.body)
+			if !errors.Is(err, base) {
+				t.Errorf("base error is lost: %v", err)
+			}
+
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.Code != 429 {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if s := statusErr.Error(); s != tc.expected {
+				t.Errorf("unexpected error text: %q", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Record is an audit record of a single generation.
+type Record struct {
+	Time         time.Time `json:"time"`
+	UserID       int64     `json:"user_id"`
+	Username     string    `json:"username"`
+	ChatID       int64     `json:"chat_id"`
+	MessageID    int       `json:"message_id"`
+	Prompt       string    `json:"prompt,omitempty"`
+	PromptLength int       `json:"prompt_length"` // in characters, it is kept if the prompt is redacted
+	Response     string    `json:"response,omitempty"`
+	Tokens       int64     `json:"tokens"`
+	Cached       bool      `json:"cached,omitempty"`
+	LatencyMS    int64     `json:"latency_ms"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Logger writes audit records as JSON lines.
+// A nil logger is valid and does nothing, it is the case of disabled audit.
+type Logger struct {
+	sync.Mutex
+	w      io.WriteCloser
+	redact bool // do not write prompt texts
+}
+
+// New returns a new audit logger writing to w.
+func New(w io.WriteCloser, redact bool) *Logger {
+	return &Logger{w: w, redact: redact}
+}
+
+// Log writes the record.
+func (l *Logger) Log(r Record) error {
+	if l == nil {
+		return nil
+	}
+
+	r.PromptLength = len([]rune(r.Prompt))
+	if l.redact {
+		r.Prompt = ""
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if _, err = l.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying writer.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	return l.w.Close()
+}
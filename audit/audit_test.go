@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type buffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *buffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestLogger(t *testing.T) {
+	testCases := []struct {
+		name   string
+		redact bool
+		prompt string
+	}{
+		{name: "full", prompt: "Кто ты?"},
+		{name: "redacted", redact: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			w := &buffer{}
+			l := New(w, tc.redact)
+
+			r := Record{
+				Time:      time.Now(),
+				UserID:    1,
+				Username:  "test",
+				ChatID:    2,
+				MessageID: 3,
+				Prompt:    "Кто ты?",
+				Response:  "Меня зовут Алиса",
+				Tokens:    20,
+				LatencyMS: 100,
+			}
+
+			for j := 0; j < 2; j++ {
+				if err := l.Log(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !w.closed {
+				t.Error("writer is not closed")
+			}
+
+			lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+			if n := len(lines); n != 2 {
+				t.Fatalf("unexpected lines: %d", n)
+			}
+
+			result := Record{}
+			if err := json.Unmarshal([]byte(lines[0]), &result); err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Prompt != tc.prompt {
+				t.Errorf("unexpected prompt: %q", result.Prompt)
+			}
+
+			if result.PromptLength != 7 || result.Response != r.Response || result.Tokens != 20 {
+				t.Errorf("unexpected record: %+v", result)
+			}
+		})
+	}
+}
+
+func TestNilLogger(t *testing.T) {
+	var l *Logger
+
+	if err := l.Log(Record{}); err != nil {
+		t.Error(err)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Error(err)
+	}
+}
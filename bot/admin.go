@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+)
+
+// isAdmin returns true if the user can run admin commands.
+func (b *Bot) isAdmin(user *telebot.User) bool {
+	return user != nil && slices.Contains(b.cfg.Admins, user.ID)
+}
+
+// maintenanceMiddleware replies with the maintenance notice to all users except admins if the mode is on.
+func (b *Bot) maintenanceMiddleware() telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			if !b.maintenance.Load() || b.isAdmin(c.Sender()) {
+				return next(c)
+			}
+
+			if c.Callback() != nil {
+				return c.Respond(&telebot.CallbackResponse{Text: tr(c, i18n.MaintenanceNotice)})
+			}
+
+			return c.Send(tr(c, i18n.MaintenanceNotice))
+		}
+	}
+}
+
+// statsHandler shows uptime, version and usage counters.
+func (b *Bot) statsHandler(c telebot.Context) error {
+	var (
+		s     = b.stats.snapshot()
+		lines = []string{
+			tr(c, i18n.StatsUptime, s.Uptime.Truncate(time.Second)),
+			tr(c, i18n.StatsVersion, valueOrUnknown(b.cfg.Version), valueOrUnknown(b.cfg.Revision)),
+			tr(c, i18n.StatsRequests, s.Requests, s.Errors, s.ErrorRate()),
+			tr(c, i18n.StatsTokens, s.Tokens, s.TokensToday),
+		}
+	)
+
+	if b.cache != nil {
+		cs := b.cache.Stats()
+		lines = append(lines, tr(c, i18n.StatsCache, cs.Size, cs.Hits, cs.Misses))
+	}
+
+	if b.cfg.LogLevel != nil {
+		lines = append(lines, tr(c, i18n.LogLevel, b.cfg.LogLevel.Level()))
+	}
+
+	lines = append(lines, tr(c, i18n.MaintenanceMode, onOff(b.maintenance.Load())))
+	return c.Send(strings.Join(lines, "\n"))
+}
+
+// broadcastHandler sends the command text to all users from the config.
+func (b *Bot) broadcastHandler(c telebot.Context) error {
+	text := commandPayload(c.Message())
+	if text == "" {
+		return c.Send(tr(c, i18n.BroadcastUsage))
+	}
+
+	var sent, failed int
+	for _, userID := range b.cfg.Users {
+		if _, err := b.bot.Send(telebot.ChatID(userID), text); err != nil {
+			logger(c).Warn("failed to broadcast", "to", userID, "error", err)
+			failed++
+			continue
+		}
+		sent++
+	}
+
+	logger(c).Info("broadcast", "sent", sent, "failed", failed)
+	return c.Send(tr(c, i18n.BroadcastSent, sent, failed))
+}
+
+// logLevelHandler shows or changes the logging level.
+func (b *Bot) logLevelHandler(c telebot.Context) error {
+	if b.cfg.LogLevel == nil {
+		return c.Send(tr(c, i18n.LogLevelNotConfigured))
+	}
+
+	payload := strings.TrimSpace(c.Message().Payload)
+	if payload == "" {
+		return c.Send(tr(c, i18n.LogLevel, b.cfg.LogLevel.Level()))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(payload)); err != nil {
+		return c.Send(tr(c, i18n.LogLevelUsage))
+	}
+
+	b.cfg.LogLevel.Set(level)
+	logger(c).Warn("log level is changed", "level", level)
+
+	return c.Send(tr(c, i18n.LogLevel, level))
+}
+
+// maintenanceHandler shows or switches the maintenance mode.
+func (b *Bot) maintenanceHandler(c telebot.Context) error {
+	switch strings.ToLower(strings.TrimSpace(c.Message().Payload)) {
+	case "":
+	case "on":
+		b.maintenance.Store(true)
+	case "off":
+		b.maintenance.Store(false)
+	default:
+		return c.Send(tr(c, i18n.MaintenanceUsage))
+	}
+
+	mode := onOff(b.maintenance.Load())
+	logger(c).Warn("maintenance", "mode", mode)
+
+	return c.Send(tr(c, i18n.MaintenanceMode, mode))
+}
+
+// onOff returns a text value of the flag.
+func onOff(value bool) string {
+	if value {
+		return "on"
+	}
+
+	return "off"
+}
+
+// valueOrUnknown returns the value or "unknown" if it is empty.
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+
+	return value
+}
@@ -0,0 +1,194 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+)
+
+// adminConfig sets the test user as admin of the two bot users.
+func adminConfig(cfg *config.Config) {
+	cfg.Users = []int64{1, 2}
+	cfg.Admins = []int64{1}
+	cfg.LogLevel = new(slog.LevelVar)
+	cfg.Version = "v1.0.0"
+	cfg.Cache = config.Cache{Size: 10, TTL: config.TimeDuration{Duration: time.Minute}}
+}
+
+// newAdminBot returns a bot with the test user as admin, it does not use Yandex APIs.
+func newAdminBot(t *testing.T) *Bot {
+	b, _ := newFixture(t, http.NotFoundHandler(), adminConfig)
+	return b
+}
+
+// commandContext returns a test context with the command message.
+func commandContext(text string) *testContext {
+	command, payload, _ := strings.Cut(text, " ")
+	message := &telebot.Message{ID: 2, Text: command + " " + payload, Payload: payload, Chat: &telebot.Chat{ID: 1}}
+
+	return &testContext{update: telebot.Update{Message: message}}
+}
+
+func TestStatsHandler(t *testing.T) {
+	b := newAdminBot(t)
+	b.stats.add(100, false)
+	b.stats.add(0, true)
+
+	c := commandContext("/stats")
+	if err := b.statsHandler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	text, _ := c.lastSent().(string)
+	expected := []string{
+		"Version: v1.0.0, revision: unknown",
+		"Requests: 2, errors: 1 (50.0%)",
+		"Tokens: 100, today: 100",
+		"Cache: size 0, hits 0, misses 0",
+		"Log level: INFO",
+		"Maintenance: off",
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(text, line) {
+			t.Errorf("no %q in stats:\n%s", line, text)
+		}
+	}
+}
+
+func TestBroadcastHandler(t *testing.T) {
+	b, tg := newFixture(t, http.NotFoundHandler(), adminConfig)
+
+	c := commandContext("/broadcast")
+	if err := b.broadcastHandler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := c.lastSent(); text != "Usage: /broadcast <text>" {
+		t.Errorf("unexpected reply: %v", text)
+	}
+
+	c = commandContext("/broadcast planned restart")
+	if err := b.broadcastHandler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := c.lastSent(); text != "Broadcast is sent: 2, failed: 0." {
+		t.Errorf("unexpected reply: %v", text)
+	}
+
+	if n, text := len(tg.Calls("sendMessage")), tg.LastText(); n != 2 || text != "planned restart" {
+		t.Errorf("unexpected sent messages: %d, %q", n, text)
+	}
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	b := newAdminBot(t)
+
+	testCases := []struct {
+		name     string
+		command  string
+		expected string
+		level    slog.Level
+	}{
+		{name: "current", command: "/loglevel", expected: "Log level: INFO", level: slog.LevelInfo},
+		{name: "debug", command: "/loglevel debug", expected: "Log level: DEBUG", level: slog.LevelDebug},
+		{name: "upper", command: "/loglevel ERROR", expected: "Log level: ERROR", level: slog.LevelError},
+		{name: "bad", command: "/loglevel verbose", expected: "Usage: /loglevel [debug|info|warn|error]", level: slog.LevelError},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			c := commandContext(tc.command)
+			if err := b.logLevelHandler(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if text := c.lastSent(); text != tc.expected {
+				t.Errorf("unexpected reply: %v", text)
+			}
+
+			if level := b.cfg.LogLevel.Level(); level != tc.level {
+				t.Errorf("unexpected level: %v", level)
+			}
+		})
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	b := newAdminBot(t)
+
+	for _, command := range []string{"/maintenance on", "/maintenance"} {
+		c := commandContext(command)
+		if err := b.maintenanceHandler(c); err != nil {
+			t.Fatal(err)
+		}
+
+		if text := c.lastSent(); text != "Maintenance: on" {
+			t.Errorf("unexpected reply: %v", text)
+		}
+	}
+
+	var called bool
+	handler := b.maintenanceMiddleware()(func(c telebot.Context) error {
+		called = true
+		return nil
+	})
+
+	// the test context sender is admin
+	if err := handler(&testContext{}); err != nil || !called {
+		t.Errorf("admin is not allowed: %v", err)
+	}
+
+	b.cfg.Admins = nil
+	called = false
+
+	c := &testContext{}
+	if err := handler(c); err != nil || called {
+		t.Errorf("user is allowed: %v", err)
+	}
+
+	if text := c.lastSent(); text != i18n.English.T(i18n.MaintenanceNotice) {
+		t.Errorf("unexpected reply: %v", text)
+	}
+
+	c = commandContext("/maintenance off")
+	if err := b.maintenanceHandler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := handler(&testContext{}); err != nil || !called {
+		t.Errorf("user is not allowed: %v", err)
+	}
+
+	if err := b.maintenanceHandler(commandContext("/maintenance maybe")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCountRequest(t *testing.T) {
+	b := newAdminBot(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b.countRequest(ctx, &config.Answer{Tokens: 10}, nil)
+	b.countRequest(ctx, &config.Answer{Tokens: 10, Cached: true}, nil)
+	b.countRequest(ctx, nil, errors.New("test"))
+
+	cancel()
+	b.countRequest(ctx, nil, context.Canceled)
+
+	snapshot := b.stats.snapshot()
+	if snapshot.Requests != 4 || snapshot.Errors != 1 || snapshot.Tokens != 10 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+}
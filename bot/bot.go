@@ -2,35 +2,56 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/telebot.v3"
 	"gopkg.in/telebot.v3/middleware"
 
+	"github.com/z0rr0/tgtpgybot/audit"
+	"github.com/z0rr0/tgtpgybot/cache"
 	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/rotate"
+	"github.com/z0rr0/tgtpgybot/tokens"
+	"github.com/z0rr0/tgtpgybot/tracing"
 )
 
 // Bot is main bot structure.
 type Bot struct {
-	cfg  *config.Config
-	bot  *telebot.Bot
-	stop chan struct{}
+	cfg           *config.Config
+	bot           *telebot.Bot
+	tracker       *tracker
+	settings      *settings
+	forwarder     *forwarder
+	templates     *templates
+	history       *history
+	audit         *audit.Logger
+	cache         *cache.Cache[config.Answer]
+	stats         *stats
+	confirmations *confirmations
+	tokens        *tokens.Counter
+	maintenance   atomic.Bool // reply with the maintenance notice to non-admin users
+	stop          chan struct{}
 }
 
 // New creates new bot.
 func New(cfg *config.Config) (*Bot, error) {
-	poller := telebot.LongPoller{Timeout: 30 * time.Second, AllowedUpdates: []string{"message", "edited_message"}}
+	poller := telebot.LongPoller{Timeout: 30 * time.Second, AllowedUpdates: []string{"message", "edited_message", "callback_query"}}
 
 	pref := telebot.Settings{
+		URL:         cfg.TelegramURL,
 		Token:       cfg.Token,
 		Poller:      &poller,
-		Synchronous: true,
+		Synchronous: false, // edited messages should be able to cancel in-flight generations
 		Verbose:     cfg.VerboseBot,
 		Offline:     cfg.Offline,
 	}
@@ -40,12 +61,35 @@ func New(cfg *config.Config) (*Bot, error) {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
 	}
 
-	// print some log info
-	b.Use(middleware.Whitelist(cfg.Users...))
+	auditLogger, err := newAuditLogger(&cfg.Audit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	bot := &Bot{
+		cfg:           cfg,
+		bot:           b,
+		tracker:       newTracker(),
+		settings:      newSettings(),
+		forwarder:     newForwarder(cfg.ForwardWindow.Duration),
+		templates:     newTemplates(cfg.Templates),
+		history:       newHistory(cfg.HistorySize),
+		audit:         auditLogger,
+		cache:         newCache(&cfg.Cache),
+		stats:         newStats(),
+		confirmations: newConfirmations(),
+		tokens:        newTokenCounter(&cfg.Chat),
+		stop:          make(chan struct{}),
+	}
+
 	// allow only users from config
-	b.Use(durationMiddleware())
+	b.Use(middleware.Whitelist(cfg.Users...))
+	// request-scoped logger and language, duration and trace span of handlers
+	b.Use(loggerMiddleware(), bot.langMiddleware(), durationMiddleware(), tracingMiddleware())
+	// maintenance notice for non-admin users
+	b.Use(bot.maintenanceMiddleware())
 
-	return &Bot{cfg: cfg, bot: b, stop: make(chan struct{})}, nil
+	return bot, nil
 }
 
 // Start starts the bot.
@@ -58,11 +102,43 @@ func (b *Bot) Start(sigChan chan os.Signal) {
 
 		slog.Info("stopping", "signal", s)
 		b.bot.Stop()
+		b.tracker.stop(errStopped)
+		b.tracker.wait()
+
+		if err := b.audit.Close(); err != nil {
+			slog.Error("failed to close audit log", "error", err)
+		}
 		close(b.stop)
 	}()
 
+	b.bot.Handle("/cancel", b.cancelHandler)
+	b.bot.Handle("/voice", b.voiceModeHandler)
+	b.bot.Handle("/say", b.sayHandler)
+	b.bot.Handle("/document", b.documentInfoHandler)
+	b.bot.Handle("/template", b.templateHandler)
+	b.bot.Handle("/t", b.templatePromptHandler)
+	b.bot.Handle("/summarize", b.summarizeHandler)
+	b.bot.Handle("/export", b.exportHandler)
+	b.bot.Handle("/import", b.importHandler)
+	b.bot.Handle("/reset", b.resetHandler)
+	b.bot.Handle("/memory", b.memoryHandler)
+	b.bot.Handle("/lang", b.langHandler)
+
+	admin := middleware.Whitelist(b.cfg.Admins...)
+	b.bot.Handle("/stats", b.statsHandler, admin)
+	b.bot.Handle("/broadcast", b.broadcastHandler, admin)
+	b.bot.Handle("/loglevel", b.logLevelHandler, admin)
+	b.bot.Handle("/maintenance", b.maintenanceHandler, admin)
+
+	b.bot.Handle(&btnStop, b.stopHandler)
+	b.bot.Handle(&btnSecretsSend, b.secretsHandler(btnSecretsSend))
+	b.bot.Handle(&btnSecretsMask, b.secretsHandler(btnSecretsMask))
+	b.bot.Handle(&btnSecretsCancel, b.secretsHandler(btnSecretsCancel))
 	b.bot.Handle(telebot.OnText, b.rootHandler)
 	b.bot.Handle(telebot.OnEdited, b.rootHandler)
+	b.bot.Handle(telebot.OnVoice, b.voiceHandler)
+	b.bot.Handle(telebot.OnDocument, b.documentHandler)
+	b.bot.Handle(telebot.OnPhoto, b.photoHandler)
 
 	b.bot.Start() // run forever, wait signal to stop
 }
@@ -73,26 +149,189 @@ func (b *Bot) Stop() {
 }
 
 // rootHandler handles incoming completion messages.
+// If the message is edited, the answer is regenerated and the previous bot's reply is edited in place.
 func (b *Bot) rootHandler(c telebot.Context) error {
+	if isForwarded(c.Message()) && c.Update().EditedMessage == nil {
+		return b.forwardHandler(c)
+	}
+
+	return b.answer(c, prompt{content: strings.TrimSpace(c.Text())})
+}
+
+// prompt is a user's request to generate an answer.
+type prompt struct {
+	content     string
+	instruction string // optional instruction text, the chat document is used if it is empty
+	speak       bool   // send the answer as a voice note too
+	confirmed   bool   // the content secrets are checked or confirmed by the user
+}
+
+// answer generates an answer for the prompt message and sends it.
+func (b *Bot) answer(c telebot.Context, p prompt) error {
 	var (
-		user      = c.Sender()
-		messageID = c.Message().ID
-		content   = strings.TrimSpace(c.Text())
+		log    = logger(c)
+		key    = newMsgKey(c.Message())
+		edited = c.Update().EditedMessage != nil
 	)
 
-	slog.Info("generation", "id", messageID, "userID", user.ID)
-	slog.Debug("generation", "id", messageID, "userID", user.ID, "text", content)
+	p, wait, err := b.checkSecrets(c, key, p)
+	if err != nil || wait {
+		return err
+	}
+
+	content := p.content
+
+	log.Info("generation", "edited", edited)
+	log.Debug("generation", "text", content)
 
-	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.Timeout.Duration)
-	defer cancel()
+	ctx, finish := b.tracker.start(key, b.cfg.Timeout.Duration)
+	defer finish()
+
+	ctx = withUpdateSpan(ctx, c)
+
+	start := time.Now()
+
+	if err := b.sendResult(c, key, tr(c, i18n.Generating), stopMarkup(c, key)); err != nil {
+		return err
+	}
+
+	turns := b.historyTurns(ctx, key)
+	request := &config.Prompt{
+		Text:        content,
+		Instruction: p.instruction,
+	}
+	if request.Instruction == "" {
+		request.Instruction = b.documentInstruction(c, key.chatID, content)
+	}
+
+	if request.Instruction == "" {
+		request.Instruction = tr(c, i18n.DefaultInstruction)
+	} else {
+		request.Instruction = b.maskInstruction(c, key, request.Instruction)
+	}
+
+	if input, budget := b.fitBudget(ctx, c, key, request, turns); input > budget {
+		log.Warn("input exceeds the budget", "tokens", input, "budget", budget)
+		return b.sendResult(c, key, tr(c, i18n.InputTooLarge, input, budget), nil)
+	}
+
+	result, err := b.generate(ctx, c, request, key.messageID)
+	b.auditLog(c, key, content, result, err, start)
+	b.countRequest(ctx, result, err)
 
-	result, err := b.cfg.Chat.Generation(ctx, content, messageID)
 	if err != nil {
-		slog.Error("failed", "id", messageID, "error", err)
-		result = "ERROR: failed to get completion: " + err.Error()
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return b.cancelled(c, key, context.Cause(ctx))
+		}
+
+		return b.sendResult(c, key, tr(c, i18n.CompletionFailed, userError(c, err)), nil)
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		tracing.AttrModel.String(string(result.Model)),
+		tracing.AttrTokens.Int64(result.Tokens),
+		tracing.AttrCached.Bool(result.Cached),
+	)
+
+	b.history.add(key.chatID, turn{
+		MessageID: key.messageID,
+		Time:      time.Now().UTC(),
+		Prompt:    content,
+		Answer:    result.Text,
+		Tokens:    result.Tokens,
+		Model:     result.Model,
+		Options:   result.Options,
+	})
+
+	if err = b.sendResult(c, key, result.Text, nil); err != nil {
+		return err
 	}
 
-	return prettyResult(c, messageID, result)
+	if p.speak || b.settings.get(key.chatID).voice {
+		return b.say(ctx, c, key.messageID, result.Text)
+	}
+
+	return nil
+}
+
+// newAuditLogger returns an audit logger writing to the rotated file, it is nil if the audit is disabled.
+func newAuditLogger(cfg *config.Audit) (*audit.Logger, error) {
+	if cfg.Path == "" {
+		return nil, nil
+	}
+
+	f, err := rotate.New(cfg.Path, cfg.MaxSize, cfg.MaxAge.Duration, cfg.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	return audit.New(f, cfg.Redact), nil
+}
+
+// auditLog writes the generation audit record.
+func (b *Bot) auditLog(c telebot.Context, key msgKey, content string, result *config.Answer, err error, start time.Time) {
+	var (
+		user   = c.Sender()
+		record = audit.Record{
+			Time:      start.UTC(),
+			UserID:    user.ID,
+			Username:  user.Username,
+			ChatID:    key.chatID,
+			MessageID: key.messageID,
+			Prompt:    content,
+			LatencyMS: time.Since(start).Milliseconds(),
+		}
+	)
+
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.Response, record.Tokens, record.Cached = result.Text, result.Tokens, result.Cached
+	}
+
+	if e := b.audit.Log(record); e != nil {
+		logger(c).Error("failed to write audit record", "error", e)
+	}
+}
+
+// sendResult sends the result as a reply to the prompt message
+// or edits the previous bot's answer if it exists.
+func (b *Bot) sendResult(c telebot.Context, key msgKey, result string, markup *telebot.ReplyMarkup) error {
+	var send sendFunc
+
+	if prev, ok := b.tracker.answer(key); ok {
+		send = tracedSend(c, "editMessageText", func(opts *telebot.SendOptions) (*telebot.Message, error) {
+			opts.ReplyMarkup = markup
+			return b.bot.Edit(prev, result, opts)
+		})
+	} else {
+		send = tracedSend(c, "sendMessage", func(opts *telebot.SendOptions) (*telebot.Message, error) {
+			opts.ReplyMarkup = markup
+			return b.bot.Send(c.Recipient(), result, opts)
+		})
+	}
+
+	m, err := prettyResult(key.messageID, result, send)
+	if err != nil {
+		if errors.Is(err, telebot.ErrSameMessageContent) || errors.Is(err, telebot.ErrMessageNotModified) {
+			logger(c).Info("answer is not modified")
+			return nil
+		}
+
+		return err
+	}
+
+	b.tracker.setAnswer(key, m)
+	return nil
+}
+
+// commandPayload returns a text after the command, unlike telebot's payload it keeps all lines.
+func commandPayload(m *telebot.Message) string {
+	if i := strings.IndexAny(m.Text, " \t\n"); i >= 0 {
+		return strings.TrimSpace(m.Text[i:])
+	}
+
+	return ""
 }
 
 // durationMiddleware is common middleware function to log duration of handler.
@@ -100,22 +339,17 @@ func durationMiddleware() telebot.MiddlewareFunc {
 	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
 		return func(c telebot.Context) error {
 			var (
-				start     = time.Now()
-				messageID = c.Message().ID
-				user      = c.Sender()
+				start = time.Now()
+				log   = logger(c)
 			)
 			defer func() {
-				slog.Info(
-					"handled",
-					"id", messageID,
-					"duration", time.Since(start).Truncate(100*time.Millisecond),
-				)
+				log.Info("handled", "duration", time.Since(start).Truncate(100*time.Millisecond))
 			}()
-			slog.Info("got", "id", messageID, "user", user.Username)
+			log.Info("got", "user", c.Sender().Username)
 
 			if err := next(c); err != nil {
 				// the error occurred inside the handler
-				return c.Send("oops, an error has occurred\n\n" + err.Error())
+				return c.Send(tr(c, i18n.HandlerFailed, userError(c, err)))
 			}
 
 			return nil
@@ -123,18 +357,22 @@ func durationMiddleware() telebot.MiddlewareFunc {
 	}
 }
 
-func prettyResult(c telebot.Context, messageID int, result string) error {
+// sendFunc sends or edits a message with the options.
+type sendFunc func(opts *telebot.SendOptions) (*telebot.Message, error)
+
+// prettyResult sends the result using markdown if it contains code blocks, with fallback to plain text.
+func prettyResult(messageID int, result string, send sendFunc) (*telebot.Message, error) {
 	if !strings.Contains(result, "```") {
-		return c.Send(result, &telebot.SendOptions{ParseMode: telebot.ModeDefault})
+		return send(&telebot.SendOptions{ParseMode: telebot.ModeDefault})
 	}
 
 	// try markdown
-	err := c.Send(result, &telebot.SendOptions{ParseMode: telebot.ModeMarkdown})
+	m, err := send(&telebot.SendOptions{ParseMode: telebot.ModeMarkdown})
 
 	if err != nil {
 		slog.Info("failed to send markdown", "id", messageID, "error", err)
-		return c.Send(result, &telebot.SendOptions{ParseMode: telebot.ModeDefault})
+		return send(&telebot.SendOptions{ParseMode: telebot.ModeDefault})
 	}
 
-	return nil
+	return m, nil
 }
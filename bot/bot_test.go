@@ -1,16 +1,23 @@
 package bot
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
-	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"gopkg.in/telebot.v3"
 
+	"github.com/z0rr0/tgtpgybot/audit"
 	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
 )
 
 func TestNew(t *testing.T) {
@@ -49,11 +56,17 @@ func TestBot_Start(t *testing.T) {
 	b.Stop()
 }
 
-type testContext struct{}
+type testContext struct {
+	sync.Mutex
+	update   telebot.Update
+	notified atomic.Int32
+	values   sync.Map
+	sent     []interface{} // sent replies
+}
 
 func (m *testContext) Bot() *telebot.Bot                                 { return nil }
-func (m *testContext) Update() telebot.Update                            { return telebot.Update{} }
-func (m *testContext) Message() *telebot.Message                         { return &telebot.Message{ID: 2, Text: "test"} }
+func (m *testContext) Update() telebot.Update                            { return m.update }
+func (m *testContext) Recipient() telebot.Recipient                      { return &telebot.Chat{ID: 1} }
 func (m *testContext) Callback() *telebot.Callback                       { return nil }
 func (m *testContext) Query() *telebot.Query                             { return nil }
 func (m *testContext) InlineResult() *telebot.InlineResult               { return nil }
@@ -64,14 +77,14 @@ func (m *testContext) ChatJoinRequest() *telebot.ChatJoinRequest         { retur
 func (m *testContext) Poll() *telebot.Poll                               { return nil }
 func (m *testContext) PollAnswer() *telebot.PollAnswer                   { return nil }
 func (m *testContext) Migration() (int64, int64)                         { return 0, 0 }
+func (m *testContext) Topic() *telebot.Topic                             { return nil }
+func (m *testContext) Boost() *telebot.BoostUpdated                      { return nil }
+func (m *testContext) BoostRemoved() *telebot.BoostRemoved               { return nil }
 func (m *testContext) Sender() *telebot.User                             { return &telebot.User{ID: 1, Username: "test"} }
-func (m *testContext) Chat() *telebot.Chat                               { return nil }
-func (m *testContext) Recipient() telebot.Recipient                      { return nil }
-func (m *testContext) Text() string                                      { return "" }
+func (m *testContext) Chat() *telebot.Chat                               { return &telebot.Chat{ID: 1} }
 func (m *testContext) Entities() telebot.Entities                        { return nil }
-func (m *testContext) Data() string                                      { return "" }
 func (m *testContext) Args() []string                                    { return []string{"arg1", "arg2"} }
-func (m *testContext) Send(interface{}, ...interface{}) error            { return nil }
+func (m *testContext) Send(what interface{}, _ ...interface{}) error     { return m.record(what) }
 func (m *testContext) SendAlbum(telebot.Album, ...interface{}) error     { return nil }
 func (m *testContext) Reply(interface{}, ...interface{}) error           { return nil }
 func (m *testContext) Forward(telebot.Editable, ...interface{}) error    { return nil }
@@ -82,96 +95,216 @@ func (m *testContext) EditOrSend(interface{}, ...interface{}) error      { retur
 func (m *testContext) EditOrReply(interface{}, ...interface{}) error     { return nil }
 func (m *testContext) Delete() error                                     { return nil }
 func (m *testContext) DeleteAfter(time.Duration) *time.Timer             { return nil }
-func (m *testContext) Notify(telebot.ChatAction) error                   { return nil }
+func (m *testContext) Notify(telebot.ChatAction) error                   { m.notified.Add(1); return nil }
 func (m *testContext) Ship(...interface{}) error                         { return nil }
 func (m *testContext) Accept(...string) error                            { return nil }
 func (m *testContext) Respond(...*telebot.CallbackResponse) error        { return nil }
+func (m *testContext) RespondText(string) error                          { return nil }
+func (m *testContext) RespondAlert(string) error                         { return nil }
 func (m *testContext) Answer(*telebot.QueryResponse) error               { return nil }
-func (m *testContext) Set(string, interface{})                           {}
-func (m *testContext) Get(string) interface{}                            { return nil }
+func (m *testContext) Set(key string, value interface{})                 { m.values.Store(key, value) }
+func (m *testContext) Get(key string) interface{}                        { v, _ := m.values.Load(key); return v }
+
+// record saves the sent reply.
+func (m *testContext) record(what interface{}) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.sent = append(m.sent, what)
+	return nil
+}
+
+// lastSent returns the latest sent reply.
+func (m *testContext) lastSent() interface{} {
+	m.Lock()
+	defer m.Unlock()
+
+	if len(m.sent) == 0 {
+		return nil
+	}
+
+	return m.sent[len(m.sent)-1]
+}
+
+func (m *testContext) Message() *telebot.Message {
+	switch {
+	case m.update.Message != nil:
+		return m.update.Message
+	case m.update.EditedMessage != nil:
+		return m.update.EditedMessage
+	case m.update.Callback != nil:
+		return m.update.Callback.Message
+	default:
+		return &telebot.Message{ID: 2, Text: "test", Chat: &telebot.Chat{ID: 1}}
+	}
+}
+
+func (m *testContext) Text() string {
+	return m.Message().Text
+}
+
+func (m *testContext) Data() string {
+	if m.update.Callback != nil {
+		return m.update.Callback.Data
+	}
+
+	return ""
+}
 
 func TestBotRootHandler(t *testing.T) {
-	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		response := `{"result":{"message":{"role":"Ассистент","text":"Меня зовут Алиса"},"num_tokens":"20"}}`
+	b, tg := newFixture(t, answerHandler(t, "Меня зовут Алиса"))
 
-		if _, err := fmt.Fprint(w, response); err != nil {
-			t.Error(err)
-		}
-	}))
-	defer s.Close()
+	if err := b.rootHandler(&testContext{}); err != nil {
+		t.Fatal(err)
+	}
 
-	cfg := &config.Config{
-		Offline: true,
-		Timeout: config.TimeDuration{Duration: 5 * time.Second},
-		Chat:    config.Chat{APIKey: "test-key", URL: s.URL, Client: s.Client()},
+	if text := tg.LastText(); text != "Меня зовут Алиса" {
+		t.Errorf("unexpected answer: %q", text)
 	}
+}
 
-	b, err := New(cfg)
+func TestBotAudit(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	b, _ := newFixture(t, answerHandler(t, "Меня зовут Алиса"), func(cfg *config.Config) {
+		cfg.Audit = config.Audit{Path: auditPath, Redact: true}
+	})
+
+	if err := b.rootHandler(&testContext{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.audit.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(auditPath)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	c := &testContext{}
-	if err = b.rootHandler(c); err != nil {
+	record := audit.Record{}
+	if err = json.Unmarshal(data, &record); err != nil {
 		t.Fatal(err)
 	}
+
+	if record.Prompt != "" || record.PromptLength != 4 || record.Response != "Меня зовут Алиса" || record.Tokens != 20 {
+		t.Errorf("unexpected audit record: %+v", record)
+	}
 }
 
-func TestDurationMiddleware(t *testing.T) {
-	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		response := `{"id":"test","created":1677652288,` +
-			`"data":[{"url":"https://127.0.0.1/test1"},{"url":"https://127.0.0.1/test2"}]}`
+func TestBotRootHandlerEdited(t *testing.T) {
+	b, tg := newFixture(t, answerHandler(t, "Меня зовут Алиса"))
+
+	message := &telebot.Message{ID: 2, Text: "test", Chat: &telebot.Chat{ID: 1}}
+	updates := []telebot.Update{{Message: message}, {EditedMessage: message}, {EditedMessage: message}}
+
+	for _, u := range updates {
+		if err := b.rootHandler(&testContext{update: u}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := len(tg.Calls("sendMessage")); n != 1 {
+		t.Errorf("unexpected number of sent messages: %d", n)
+	}
 
-		if _, err := fmt.Fprint(w, response); err != nil {
-			t.Error(err)
+	if n := len(tg.Calls("editMessageText")); n != 5 {
+		t.Errorf("unexpected number of edited messages: %d", n)
+	}
+}
+
+func TestBotCancelHandler(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+		case <-release:
 		}
 	}))
-	defer s.Close()
+	defer close(release)
 
-	cfg := &config.Config{
-		Offline: true,
-		Timeout: config.TimeDuration{Duration: 5 * time.Second},
-		Chat:    config.Chat{APIKey: "test-key", URL: s.URL, Client: s.Client()},
+	c := &testContext{}
+	if err := b.cancelHandler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- b.rootHandler(c)
+	}()
+
+	<-started
+	if err := b.cancelHandler(c); err != nil {
+		t.Fatal(err)
 	}
 
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if text := tg.LastText(); text != i18n.English.T(i18n.Cancelled) {
+		t.Errorf("unexpected answer: %q", text)
+	}
+
+	if n := len(b.tracker.inflight); n != 0 {
+		t.Errorf("unexpected number of in-flight generations: %d", n)
+	}
+}
+
+func TestBotStopHandler(t *testing.T) {
+	cfg := &config.Config{Offline: true}
+
 	b, err := New(cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	key := msgKey{chatID: 1, messageID: 2}
+	ctx, finish := b.tracker.start(key, time.Minute)
+	defer finish()
+
+	callback := &telebot.Callback{Data: "2", Message: &telebot.Message{ID: 3, Chat: &telebot.Chat{ID: 1}}}
+	if err = b.stopHandler(&testContext{update: telebot.Update{Callback: callback}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if cause := context.Cause(ctx); !errors.Is(cause, errCancelled) {
+		t.Errorf("unexpected cancellation cause: %v", cause)
+	}
+
+	callback.Data = "bad"
+	if err = b.stopHandler(&testContext{update: telebot.Update{Callback: callback}}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestDurationMiddleware(t *testing.T) {
+	b, _ := newFixture(t, answerHandler(t, "Меня зовут Алиса"))
+
 	c := &testContext{}
 	firstHandler := durationMiddleware()
 	secondHandler := firstHandler(b.rootHandler)
 
-	if err = secondHandler(c); err != nil {
+	if err := secondHandler(c); err != nil {
 		t.Fatal(err)
 	}
 }
 
 func TestFailedDurationMiddleware(t *testing.T) {
-	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "test", http.StatusInternalServerError)
 	}))
-	defer s.Close()
-
-	cfg := &config.Config{
-		Offline: true,
-		Timeout: config.TimeDuration{Duration: 5 * time.Second},
-		Chat:    config.Chat{APIKey: "test-key", URL: s.URL, Client: s.Client()},
-	}
-
-	b, err := New(cfg)
-	if err != nil {
-		t.Fatal(err)
-	}
 
 	c := &testContext{}
 	firstHandler := durationMiddleware()
 	secondHandler := firstHandler(b.rootHandler)
 
-	if err = secondHandler(c); err != nil {
+	if err := secondHandler(c); err != nil {
 		t.Fatal(err)
 	}
+
+	if text := tg.LastText(); !strings.HasPrefix(text, i18n.English.T(i18n.CompletionFailed, "")) {
+		t.Errorf("unexpected answer: %q", text)
+	}
 }
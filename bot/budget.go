@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/tokens"
+	"github.com/z0rr0/tgtpgybot/tracing"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+// newTokenCounter returns a tokens counter using the tokenizer API if it is enabled.
+func newTokenCounter(chat *config.Chat) *tokens.Counter {
+	if chat.Tokenize {
+		return tokens.New(chat.TokenCount)
+	}
+
+	return tokens.New(nil)
+}
+
+// fitBudget sets the prompt history from the chat memory and the conversation turns fitting the input tokens budget,
+// the room for the answer tokens is already reserved in the budget.
+// The oldest turns exceeding the budget or the history size are summarized into the chat memory,
+// if it fails, they are forgotten, so the failed summarization is not repeated on every next prompt.
+// It returns tokens of the instruction and text, and the budget; the history is not set
+// if this input alone exceeds the budget.
+func (b *Bot) fitBudget(ctx context.Context, c telebot.Context, key msgKey, request *config.Prompt, turns []turn) (int64, int64) {
+	ctx, span := tracing.Tracer().Start(ctx, "tokens.budget")
+	defer span.End()
+
+	var (
+		budget = b.cfg.Chat.InputBudget()
+		input  = b.tokens.Count(ctx, request.Instruction) + b.tokens.Count(ctx, request.Text)
+	)
+
+	span.SetAttributes(attribute.Int64("tokens.input", input), attribute.Int64("tokens.budget", budget))
+	if input > budget {
+		return input, budget
+	}
+
+	var (
+		prev      string // summary of the earlier turns
+		memory    *ygpt.Message
+		memTokens int64
+		counts    = make([]int64, len(turns))
+		total     int64
+		capped    int // number of the oldest turns over the history size
+	)
+
+	if b.history.enabled() && len(turns) > b.history.size {
+		capped = len(turns) - b.history.size
+	}
+
+	if m, ok := b.history.memory(key.chatID); ok {
+		message := memoryMessage(c, m.Text)
+		prev, memory = m.Text, &message
+		memTokens = b.tokens.Count(ctx, memory.Text)
+		total = memTokens
+	}
+
+	for i, t := range turns {
+		counts[i] = b.tokens.Count(ctx, t.Prompt) + b.tokens.Count(ctx, t.Answer)
+		total += counts[i]
+	}
+
+	// drop returns an index of the first turn fitting the budget and the history size after the start one
+	drop := func(start int) int {
+		for ; start < len(counts) && (start < capped || input+total > budget); start++ {
+			total -= counts[start]
+		}
+		return start
+	}
+
+	var summarized, dropped = 0, drop(0)
+	if dropped > 0 {
+		text, err := b.summarize(ctx, c, key, prev, turns[:dropped])
+		if err != nil {
+			logger(c).Warn("failed to summarize history", "error", err)
+			b.history.drop(key.chatID, turns[dropped-1].MessageID)
+		} else {
+			b.history.fold(key.chatID, turns[dropped-1].MessageID, text)
+			summarized = dropped
+
+			// the memory can grow, the next turns are summarized on the next overflow
+			total -= memTokens
+			message := memoryMessage(c, text)
+			memory = &message
+			memTokens = b.tokens.Count(ctx, memory.Text)
+			total += memTokens
+			dropped = drop(dropped)
+		}
+
+		logger(c).Info("history is trimmed", "dropped", dropped, "summarized", summarized, "tokens", input+total, "budget", budget)
+	}
+
+	if memory != nil && input+total > budget {
+		logger(c).Warn("memory exceeds the budget", "tokens", memTokens)
+		total -= memTokens
+		memory = nil
+	}
+
+	request.History = turnMessages(turns[dropped:])
+	if memory != nil {
+		request.History = append([]ygpt.Message{*memory}, request.History...)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("tokens.history", total),
+		attribute.Int("history.dropped", dropped),
+		attribute.Int("history.summarized", summarized),
+	)
+	return input, budget
+}
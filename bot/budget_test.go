@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+func TestFitBudget(t *testing.T) {
+	cfg := &config.Config{
+		Offline: true,
+		Chat:    config.Chat{ContextTokens: 30, MaxTokens: 20},
+	}
+
+	b, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var turns []turn
+	for i := 0; i < 3; i++ {
+		turns = append(turns, turn{MessageID: i + 1, Prompt: "one two three", Answer: "four five"})
+	}
+
+	testCases := []struct {
+		name    string
+		text    string
+		input   int64
+		history int
+	}{
+		{name: "fit", text: "hi all", input: 2, history: 2},
+		{name: "short", text: "hi", input: 1, history: 2},
+		{name: "large", text: strings.Repeat("word ", 11), input: 11, history: 0},
+		{name: "empty", text: strings.Repeat("word ", 10), input: 10, history: 0},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			request := &config.Prompt{Text: tc.text}
+
+			input, budget := b.fitBudget(context.Background(), &testContext{}, msgKey{chatID: 1, messageID: 4}, request, turns)
+			if input != tc.input || budget != 10 {
+				t.Errorf("unexpected input and budget: %d, %d", input, budget)
+			}
+
+			if n := len(request.History); n != tc.history {
+				t.Errorf("unexpected history length: %d", n)
+			}
+
+			if n := len(request.History); n > 0 && request.History[0].Role != ygpt.RoleUser {
+				t.Errorf("history starts with an answer: %v", request.History[0])
+			}
+		})
+	}
+}
+
+func TestInputTooLarge(t *testing.T) {
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("too large prompt is sent")
+	}), func(cfg *config.Config) {
+		cfg.Chat.ContextTokens, cfg.Chat.MaxTokens = 30, 20
+	})
+
+	text := strings.Repeat("word ", 20)
+	c := &testContext{update: telebot.Update{Message: &telebot.Message{ID: 2, Text: text, Chat: &telebot.Chat{ID: 1}}}}
+
+	if err := b.rootHandler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// instruction tokens are counted too
+	expected := i18n.English.T(i18n.InputTooLarge, 20+b.tokens.Count(context.Background(), i18n.English.T(i18n.DefaultInstruction)), 10)
+	if text = tg.LastText(); text != expected {
+		t.Errorf("unexpected reply: %q", text)
+	}
+}
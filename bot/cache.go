@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/z0rr0/tgtpgybot/cache"
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+// newCache returns an answers cache, it is nil if the cache is disabled.
+func newCache(cfg *config.Cache) *cache.Cache[config.Answer] {
+	if cfg.Size <= 0 {
+		return nil
+	}
+
+	return cache.New[config.Answer](cfg.Size, cfg.TTL.Duration)
+}
+
+// cacheKey returns a cache key of the prompt.
+// The prompt is not cacheable if its answer depends on the conversation or it is not deterministic.
+func (b *Bot) cacheKey(prompt *config.Prompt) (string, bool) {
+	options := b.cfg.Chat.GenerationOptions()
+
+	if b.cache == nil || len(prompt.History) > 0 || options.Temperature > 0 {
+		return "", false
+	}
+
+	data := fmt.Sprintf("%s\x00%v\x00%d\x00%s\x00%s", ygpt.ModelGeneral, options.Temperature, options.MaxTokens, prompt.Instruction, normalizePrompt(prompt.Text))
+	sum := sha256.Sum256([]byte(data))
+
+	return hex.EncodeToString(sum[:]), true
+}
+
+// normalizePrompt returns the lower-cased text without leading, trailing and repeated spaces.
+func normalizePrompt(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/cache"
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/moderation"
+)
+
+func TestBotCache(t *testing.T) {
+	var calls atomic.Int32
+
+	handler := answerHandler(t, "Меня зовут Алиса")
+
+	testCases := []struct {
+		name        string
+		temperature float64
+		historySize int
+		calls       int32
+		stats       cache.Stats
+	}{
+		{name: "cached", calls: 1, stats: cache.Stats{Hits: 2, Misses: 1, Size: 1}},
+		{name: "temperature", temperature: 0.5, calls: 3},
+		{name: "history", historySize: 10, calls: 3, stats: cache.Stats{Misses: 1, Size: 1}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			calls.Store(0)
+			b, _ := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				handler(w, r)
+			}), func(cfg *config.Config) {
+				cfg.Chat.Temperature = tc.temperature
+				cfg.Cache = config.Cache{Size: 10, TTL: config.TimeDuration{Duration: time.Minute}}
+				cfg.HistorySize = tc.historySize
+			})
+
+			for j, text := range []string{"Кто ты?", "  кто  ты? ", "КТО ТЫ?"} {
+				message := &telebot.Message{ID: j + 1, Text: text, Chat: &telebot.Chat{ID: 1}}
+				if err := b.rootHandler(&testContext{update: telebot.Update{Message: message}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if n := calls.Load(); n != tc.calls {
+				t.Errorf("unexpected generation calls: %d", n)
+			}
+
+			if stats := b.cache.Stats(); stats != tc.stats {
+				t.Errorf("unexpected cache stats: %+v", stats)
+			}
+		})
+	}
+}
+
+func TestBotCacheModeration(t *testing.T) {
+	var calls atomic.Int32
+
+	handler := answerHandler(t, "Записал")
+
+	testCases := []struct {
+		name  string
+		texts []string
+		calls int32
+		text  string
+	}{
+		{
+			name:  "redacted",
+			texts: []string{"Мой адрес user@example.com", "Мой адрес admin@example.com"},
+			calls: 1,
+			text:  "Записал",
+		},
+		{
+			name:  "denied",
+			texts: []string{"Секрет", "Секрет"},
+			text:  "the request contains denied content or personal data and is not sent",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			calls.Store(0)
+			b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				handler(w, r)
+			}), func(cfg *config.Config) {
+				filter, err := moderation.New(&moderation.Options{DenyWords: []string{"секрет"}, PII: moderation.ActionRedact})
+				if err != nil {
+					t.Fatal(err)
+				}
+				cfg.Chat.Moderation.Filter = filter
+				cfg.Cache = config.Cache{Size: 10, TTL: config.TimeDuration{Duration: time.Minute}}
+			})
+
+			for j, text := range tc.texts {
+				if j > 0 {
+					// an answer cached before the moderation must not be returned
+					key, _ := b.cacheKey(&config.Prompt{Text: text, Instruction: tr(&testContext{}, i18n.DefaultInstruction)})
+					b.cache.Set(key, config.Answer{Text: "raw"})
+				}
+
+				message := &telebot.Message{ID: j + 1, Text: text, Chat: &telebot.Chat{ID: 1}}
+				if err := b.rootHandler(&testContext{update: telebot.Update{Message: message}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if n := calls.Load(); n != tc.calls {
+				t.Errorf("unexpected generation calls: %d", n)
+			}
+
+			if text := tg.LastText(); !strings.Contains(text, tc.text) {
+				t.Errorf("unexpected answer: %q", text)
+			}
+		})
+	}
+}
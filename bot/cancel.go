@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+)
+
+// btnStop is an inline button to stop the in-flight generation.
+var btnStop = telebot.InlineButton{Unique: "stop", Text: "Stop"}
+
+// stopMarkup returns an inline keyboard with the localized stop button for the prompt message.
+func stopMarkup(c telebot.Context, key msgKey) *telebot.ReplyMarkup {
+	btn := btnStop
+	btn.Text, btn.Data = tr(c, i18n.StopButton), strconv.Itoa(key.messageID)
+
+	return &telebot.ReplyMarkup{InlineKeyboard: [][]telebot.InlineButton{{btn}}}
+}
+
+// cancelled handles a cancelled generation of the prompt message.
+func (b *Bot) cancelled(c telebot.Context, key msgKey, cause error) error {
+	logger(c).Info("cancelled", "cause", cause)
+
+	if errors.Is(cause, errReplaced) {
+		// a newer version of the message is handled, it will update the answer
+		return nil
+	}
+
+	return b.sendResult(c, key, tr(c, i18n.Cancelled), nil)
+}
+
+// tracked runs the function with a generation context of the prompt message,
+// so downloads and recognitions before the generation are cancelled by the user or the bot stop too.
+// The returned cancellation error wraps its cause.
+func (b *Bot) tracked(c telebot.Context, key msgKey, f func(ctx context.Context) error) error {
+	ctx, finish := b.tracker.start(key, b.cfg.Timeout.Duration)
+	defer finish()
+
+	err := f(withUpdateSpan(ctx, c))
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return fmt.Errorf("%w: %w", context.Canceled, context.Cause(ctx))
+	}
+
+	return err
+}
+
+// cancelHandler cancels all in-flight generations in the chat.
+func (b *Bot) cancelHandler(c telebot.Context) error {
+	n := b.tracker.cancelChat(c.Chat().ID, errCancelled)
+	if n == 0 {
+		return c.Send(tr(c, i18n.NoGenerations))
+	}
+
+	return c.Send(tr(c, i18n.CancelledGenerations, n))
+}
+
+// stopHandler cancels the in-flight generation by the inline stop button.
+func (b *Bot) stopHandler(c telebot.Context) error {
+	messageID, err := strconv.Atoi(c.Data())
+	if err != nil {
+		return fmt.Errorf("invalid stop button data %q: %w", c.Data(), err)
+	}
+
+	key := msgKey{chatID: c.Chat().ID, messageID: messageID}
+	response := &telebot.CallbackResponse{Text: tr(c, i18n.Stopped)}
+
+	if !b.tracker.cancel(key, errCancelled) {
+		response.Text = tr(c, i18n.AlreadyFinished)
+	}
+
+	return c.Respond(response)
+}
@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/document"
+	"github.com/z0rr0/tgtpgybot/i18n"
+)
+
+// documentHandler loads an uploaded document to use it as a context for next prompts in the chat.
+// If the document has a caption, it is handled as a prompt.
+// A document with "/import" caption is a conversation export to restore.
+func (b *Bot) documentHandler(c telebot.Context) error {
+	var (
+		message = c.Message()
+		doc     = message.Document
+		limits  = b.cfg.Documents
+	)
+
+	if strings.HasPrefix(strings.TrimSpace(message.Caption), "/import") {
+		return b.importHandler(c)
+	}
+
+	if !document.Supported(doc.FileName) {
+		return c.Send(tr(c, i18n.DocumentUnsupported, doc.FileName, strings.Join(document.Extensions, ", ")))
+	}
+
+	if doc.FileSize > limits.MaxSize {
+		return c.Send(tr(c, i18n.DocumentTooLarge, limits.MaxSize))
+	}
+
+	var (
+		key = newMsgKey(message)
+		d   *document.Document
+	)
+
+	err := b.tracked(c, key, func(ctx context.Context) (err error) {
+		d, err = b.loadDocument(ctx, message.ID, doc)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return b.cancelled(c, key, err)
+		}
+		return c.Send(tr(c, i18n.DocumentFailed, userError(c, err)))
+	}
+
+	b.settings.update(c.Chat().ID, func(cs *chatSettings) { cs.document = d })
+	logger(c).Info("document loaded", "name", d.Name, "length", d.Length, "chunks", len(d.Chunks))
+
+	if caption := strings.TrimSpace(message.Caption); caption != "" {
+		return b.answer(c, prompt{content: caption})
+	}
+
+	return c.Send(tr(c, i18n.DocumentLoaded, d.Name, d.Length))
+}
+
+// loadDocument downloads the document from Telegram and splits its text into chunks.
+// The download is interrupted if the context is done.
+func (b *Bot) loadDocument(ctx context.Context, messageID int, doc *telebot.Document) (*document.Document, error) {
+	reader, err := b.bot.File(&doc.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	// closing the file stops its reading
+	stop := context.AfterFunc(ctx, func() { _ = reader.Close() })
+	defer func() {
+		if stop() {
+			if e := reader.Close(); e != nil {
+				slog.Error("failed to close file", "id", messageID, "error", e)
+			}
+		}
+	}()
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// file size can be unknown, so read one more byte to check the limit
+	maxSize := b.cfg.Documents.MaxSize
+	data, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("document is too large, maximum size is %d bytes", maxSize)
+	}
+
+	text, err := document.Extract(doc.FileName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return document.New(doc.FileName, text, b.cfg.Documents.ChunkSize), nil
+}
+
+// documentInstruction returns an instruction text with the chat document context relevant to the content.
+// It returns an empty string if there is no document in the chat.
+func (b *Bot) documentInstruction(c telebot.Context, chatID int64, content string) string {
+	d := b.settings.get(chatID).document
+	if d == nil {
+		return ""
+	}
+
+	return tr(c, i18n.DocumentInstruction, d.Name, d.Context(content, b.cfg.Documents.ContextLimit))
+}
+
+// documentInfoHandler shows the chat document info or forgets it by "clear" argument.
+func (b *Bot) documentInfoHandler(c telebot.Context) error {
+	var chatID = c.Chat().ID
+
+	switch arg := strings.TrimSpace(c.Message().Payload); arg {
+	case "clear":
+		b.settings.update(chatID, func(cs *chatSettings) { cs.document = nil })
+		return c.Send(tr(c, i18n.DocumentForgotten))
+	case "":
+	default:
+		return c.Send(tr(c, i18n.DocumentUsage))
+	}
+
+	d := b.settings.get(chatID).document
+	if d == nil {
+		return c.Send(tr(c, i18n.DocumentNone))
+	}
+
+	return c.Send(tr(c, i18n.DocumentInfo, d.Name, d.Length, len(d.Chunks)))
+}
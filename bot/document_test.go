@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+func TestBotDocumentHandler(t *testing.T) {
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &ygpt.TextGenerationChat{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		if !strings.Contains(request.InstructionText, "the answer is 42") {
+			t.Errorf("unexpected instruction: %q", request.InstructionText)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"result":{"message":{"role":"Ассистент","text":"42"},"num_tokens":"20"}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}), func(cfg *config.Config) {
+		cfg.Documents = config.Documents{MaxSize: 100, ChunkSize: 20, ContextLimit: 100}
+	})
+
+	tg.AddFile("test", []byte("# Notes\nthe answer is 42\n"))
+
+	newContext := func(id int, doc *telebot.Document, caption string) *testContext {
+		message := &telebot.Message{ID: id, Chat: &telebot.Chat{ID: 1}, Document: doc, Caption: caption}
+		return &testContext{update: telebot.Update{Message: message}}
+	}
+
+	// unsupported and too large documents are not loaded
+	docs := []*telebot.Document{
+		{File: telebot.File{FileID: "test", FileSize: 10}, FileName: "image.png"},
+		{File: telebot.File{FileID: "test", FileSize: 1000}, FileName: "notes.md"},
+	}
+
+	for _, doc := range docs {
+		if err := b.documentHandler(newContext(1, doc, "")); err != nil {
+			t.Fatal(err)
+		}
+
+		if d := b.settings.get(1).document; d != nil {
+			t.Fatalf("unexpected document: %v", d.Name)
+		}
+	}
+
+	doc := &telebot.Document{File: telebot.File{FileID: "test", FileSize: 10}, FileName: "notes.md"}
+	if err := b.documentHandler(newContext(2, doc, "What is the answer?")); err != nil {
+		t.Fatal(err)
+	}
+
+	d := b.settings.get(1).document
+	if d == nil {
+		t.Fatal("document is not loaded")
+	}
+
+	if d.Name != "notes.md" || len(d.Chunks) != 2 {
+		t.Errorf("unexpected document: %#v", d)
+	}
+
+	if text := tg.LastText(); text != "42" {
+		t.Errorf("unexpected answer: %q", text)
+	}
+
+	if err := b.documentInfoHandler(&testContext{}); err != nil {
+		t.Fatal(err)
+	}
+
+	message := &telebot.Message{ID: 3, Chat: &telebot.Chat{ID: 1}, Payload: "clear"}
+	if err := b.documentInfoHandler(&testContext{update: telebot.Update{Message: message}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if d = b.settings.get(1).document; d != nil {
+		t.Errorf("document is not forgotten: %v", d.Name)
+	}
+}
+
+func TestBotLoadDocumentCancel(t *testing.T) {
+	b, tg := newFixture(t, http.NotFoundHandler(), func(cfg *config.Config) {
+		cfg.Documents = config.Documents{MaxSize: 100, ChunkSize: 20, ContextLimit: 100}
+	})
+	tg.AddFile("test", []byte("# Notes\nthe answer is 42\n"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	doc := &telebot.Document{File: telebot.File{FileID: "test", FileSize: 10}, FileName: "notes.md"}
+	if _, err := b.loadDocument(ctx, 1, doc); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+)
+
+func TestBotEndToEnd(t *testing.T) {
+	b, tg := newFixture(t, answerHandler(t, "Меня зовут Алиса"), func(cfg *config.Config) {
+		cfg.Offline = false
+		cfg.Users = []int64{1}
+		cfg.HistorySize = 10
+	})
+
+	sigChan := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		b.Start(sigChan)
+	}()
+
+	tg.AddMessage(1, 1, "Кто ты?")
+	tg.AddMessage(2, 2, "unknown user") // it is not in the whitelist
+
+	// placeholder is sent and then edited by the answer
+	edits, err := tg.WaitCalls("editMessageText", 1, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text := edits[0].Params["text"]; text != "Меня зовут Алиса" {
+		t.Errorf("unexpected answer: %q", text)
+	}
+
+	tg.AddMessage(1, 1, "/reset")
+	if _, err = tg.WaitCalls("sendMessage", 2, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	sigChan <- os.Interrupt
+	b.Stop()
+	<-done
+
+	messages := tg.Calls("sendMessage")
+	if messages[0].Params["text"] != i18n.English.T(i18n.Generating) || messages[0].Params["chat_id"] != "1" {
+		t.Errorf("unexpected placeholder: %v", messages[0].Params)
+	}
+
+	if text := messages[1].Params["text"]; text != "Conversation is forgotten, turns: 1." {
+		t.Errorf("unexpected reset answer: %q", text)
+	}
+
+	for _, c := range tg.Calls("") {
+		if c.Params["chat_id"] == "2" {
+			t.Errorf("unexpected call for unknown user: %v", c)
+		}
+	}
+
+	if n := len(tg.Calls("sendChatAction")); n == 0 {
+		t.Error("typing action is not sent")
+	}
+}
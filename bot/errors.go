@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/apierror"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/moderation"
+)
+
+var (
+	// quotaMarkers are lowercase parts of API responses about exceeded quotas.
+	quotaMarkers = []string{"quota"}
+
+	// filterMarkers are lowercase parts of API responses about rejected by content filter requests.
+	filterMarkers = []string{"filter", "moderation", "inappropriate"}
+)
+
+// userError logs the error with a new correlation ID and returns its short localized explanation,
+// the ID is shown to the user to find the error details in logs.
+func userError(c telebot.Context, err error) string {
+	var (
+		id     = newErrorID()
+		reason = errorReason(err)
+	)
+
+	logger(c).Error("failed", "error", err, "error_id", id, "reason", reason)
+	return tr(c, i18n.ErrorReference, tr(c, reason), id)
+}
+
+// newErrorID returns a random error correlation ID.
+func newErrorID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// errorReason returns a message key of the error cause.
+func errorReason(err error) i18n.Key {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return i18n.ErrorTimeout
+	case errors.Is(err, moderation.ErrDenied), errors.Is(err, moderation.ErrPersonalData):
+		return i18n.ErrorBlocked
+	case errors.Is(err, moderation.ErrRefusal):
+		return i18n.ErrorRefused
+	}
+
+	if code, body, ok := responseStatus(err); ok {
+		body = strings.ToLower(body)
+
+		switch {
+		case code == http.StatusTooManyRequests && containsAny(body, quotaMarkers):
+			return i18n.ErrorQuota
+		case code == http.StatusTooManyRequests:
+			return i18n.ErrorRateLimit
+		case code == http.StatusPaymentRequired, code == http.StatusForbidden && containsAny(body, quotaMarkers):
+			return i18n.ErrorQuota
+		case code == http.StatusRequestTimeout, code == http.StatusGatewayTimeout:
+			return i18n.ErrorTimeout
+		case code == http.StatusBadRequest && containsAny(body, filterMarkers):
+			return i18n.ErrorFiltered
+		case code >= http.StatusInternalServerError:
+			return i18n.ErrorUnavailable
+		}
+
+		return i18n.ErrorUnknown
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return i18n.ErrorTimeout
+		}
+		return i18n.ErrorUnavailable
+	}
+
+	return i18n.ErrorUnknown
+}
+
+// responseStatus returns the response status code and body of API errors.
+func responseStatus(err error) (int, string, bool) {
+	var statusErr *apierror.StatusError
+
+	if errors.As(err, &statusErr) {
+		return statusErr.Code, statusErr.Body, true
+	}
+
+	return 0, "", false
+}
+
+// containsAny returns true if the text contains any of the substrings.
+func containsAny(text string, substrings []string) bool {
+	for _, s := range substrings {
+		if strings.Contains(text, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/z0rr0/tgtpgybot/apierror"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/moderation"
+)
+
+func TestErrorReason(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected i18n.Key
+	}{
+		{name: "deadline", err: fmt.Errorf("request: %w", context.DeadlineExceeded), expected: i18n.ErrorTimeout},
+		{name: "net_timeout", err: &net.DNSError{Err: "timeout", IsTimeout: true}, expected: i18n.ErrorTimeout},
+		{name: "net", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expected: i18n.ErrorUnavailable},
+		{name: "rate_limit", err: &apierror.StatusError{Code: 429, Body: "too many requests"}, expected: i18n.ErrorRateLimit},
+		{name: "quota_429", err: &apierror.StatusError{Code: 429, Body: "Quota exceeded"}, expected: i18n.ErrorQuota},
+		{name: "quota_403", err: &apierror.StatusError{Code: 403, Body: "quota limit"}, expected: i18n.ErrorQuota},
+		{name: "forbidden", err: &apierror.StatusError{Code: 403, Body: "permission denied"}, expected: i18n.ErrorUnknown},
+		{name: "payment", err: &apierror.StatusError{Code: 402}, expected: i18n.ErrorQuota},
+		{name: "gateway_timeout", err: &apierror.StatusError{Code: 504}, expected: i18n.ErrorTimeout},
+		{name: "filtered", err: &apierror.StatusError{Code: 400, Body: "rejected by moderation"}, expected: i18n.ErrorFiltered},
+		{name: "bad_request", err: &apierror.StatusError{Code: 400, Body: "invalid model"}, expected: i18n.ErrorUnknown},
+		{name: "unavailable", err: errors.Join(errors.New("base"), &apierror.StatusError{Code: 503}), expected: i18n.ErrorUnavailable},
+		{name: "denied", err: fmt.Errorf("moderate: %w", moderation.ErrDenied), expected: i18n.ErrorBlocked},
+		{name: "personal", err: errors.Join(moderation.ErrPersonalData, errors.New("email")), expected: i18n.ErrorBlocked},
+		{name: "refusal", err: fmt.Errorf("generate: %w", moderation.ErrRefusal), expected: i18n.ErrorRefused},
+		{name: "unknown", err: errors.New("unknown"), expected: i18n.ErrorUnknown},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			if reason := errorReason(tc.err); reason != tc.expected {
+				t.Errorf("unexpected reason: %q", reason)
+			}
+		})
+	}
+}
+
+func TestUserError(t *testing.T) {
+	var (
+		c   = &testContext{}
+		err = &apierror.StatusError{Code: 500, Body: "internal trace details"}
+	)
+
+	text := userError(c, err)
+	if strings.Contains(text, err.Body) {
+		t.Errorf("raw error is shown: %q", text)
+	}
+
+	re := regexp.MustCompile(`^the service is temporarily unavailable, try again later \(error ID: [0-9a-f]{8}\)$`)
+	if !re.MatchString(text) {
+		t.Errorf("unexpected text: %q", text)
+	}
+
+	if a, b := newErrorID(), newErrorID(); a == b {
+		t.Errorf("duplicate error IDs: %q", a)
+	}
+}
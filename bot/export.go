@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+)
+
+// maxExportSize is a maximum size of the imported conversation file.
+const maxExportSize = 1 << 20 // 1 MB
+
+// errEmptyExport is an error if the imported conversation has no turns.
+var errEmptyExport = errors.New("conversation is empty")
+
+// conversation is an exported chat history.
+type conversation struct {
+	ChatID   int64     `json:"chat_id"`
+	Exported time.Time `json:"exported"`
+	Turns    []turn    `json:"turns"`
+}
+
+// markdown returns the conversation as a Markdown transcript.
+func (conv *conversation) markdown() []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "# Conversation\n\nExported: %s\n", conv.Exported.Format(time.RFC3339))
+
+	for _, t := range conv.Turns {
+		fmt.Fprintf(
+			&buf, "\n## %s\n\n_model: %s, temperature: %v, max tokens: %d, tokens: %d_\n\n",
+			t.Time.Format(time.RFC3339), t.Model, t.Options.Temperature, t.Options.MaxTokens, t.Tokens,
+		)
+		fmt.Fprintf(&buf, "**User:**\n\n%s\n\n**Assistant:**\n\n%s\n", t.Prompt, t.Answer)
+	}
+
+	return buf.Bytes()
+}
+
+// exportDocument returns the chat conversation as a document in "md" or "json" format.
+func (b *Bot) exportDocument(chatID int64, format string) (*telebot.Document, error) {
+	conv := &conversation{ChatID: chatID, Exported: time.Now().UTC(), Turns: b.history.turns(chatID)}
+	if len(conv.Turns) == 0 {
+		return nil, errEmptyExport
+	}
+
+	var (
+		data     []byte
+		err      error
+		fileName = fmt.Sprintf("conversation_%d_%s", chatID, conv.Exported.Format("20060102_150405"))
+		doc      = &telebot.Document{}
+	)
+
+	switch format {
+	case "", "md":
+		data = conv.markdown()
+		doc.FileName, doc.MIME = fileName+".md", "text/markdown"
+	case "json":
+		if data, err = json.MarshalIndent(conv, "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to marshal conversation: %w", err)
+		}
+		doc.FileName, doc.MIME = fileName+".json", "application/json"
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+
+	doc.File = telebot.FromReader(bytes.NewReader(data))
+	return doc, nil
+}
+
+// exportHandler sends the chat conversation as a Markdown or JSON document.
+func (b *Bot) exportHandler(c telebot.Context) error {
+	var (
+		message = c.Message()
+		format  = strings.ToLower(strings.TrimSpace(message.Payload))
+	)
+
+	if format != "" && format != "md" && format != "json" {
+		return c.Send(tr(c, i18n.ExportUsage))
+	}
+
+	doc, err := b.exportDocument(c.Chat().ID, format)
+	if err != nil {
+		if errors.Is(err, errEmptyExport) {
+			return c.Send(tr(c, i18n.HistoryEmpty))
+		}
+
+		return c.Send(tr(c, i18n.ExportFailed, userError(c, err)))
+	}
+
+	return c.Send(doc)
+}
+
+// importHandler restores the chat conversation from a JSON export.
+// The export is a document with "/import" caption or a replied document.
+func (b *Bot) importHandler(c telebot.Context) error {
+	var (
+		message = c.Message()
+		doc     = message.Document
+	)
+
+	if !b.history.enabled() {
+		return c.Send(tr(c, i18n.HistoryDisabled))
+	}
+
+	if doc == nil && message.ReplyTo != nil {
+		doc = message.ReplyTo.Document
+	}
+
+	if doc == nil {
+		return c.Send(tr(c, i18n.ImportUsage))
+	}
+
+	if doc.FileSize > maxExportSize {
+		return c.Send(tr(c, i18n.ImportTooLarge, maxExportSize))
+	}
+
+	turns, err := b.loadConversation(message.ID, &doc.File)
+	if err != nil {
+		return c.Send(tr(c, i18n.ImportFailed, userError(c, err)))
+	}
+
+	b.maskTurns(c, c.Chat().ID, turns)
+	b.history.replace(c.Chat().ID, turns)
+	logger(c).Info("conversation imported", "turns", len(turns))
+
+	return c.Send(tr(c, i18n.Imported, len(b.history.turns(c.Chat().ID))))
+}
+
+// loadConversation downloads the JSON export from Telegram and returns its turns.
+func (b *Bot) loadConversation(messageID int, file *telebot.File) ([]turn, error) {
+	reader, err := b.bot.File(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	defer func() {
+		if e := reader.Close(); e != nil {
+			slog.Error("failed to close file", "id", messageID, "error", e)
+		}
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxExportSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if len(data) > maxExportSize {
+		return nil, fmt.Errorf("export is too large, maximum size is %d bytes", maxExportSize)
+	}
+
+	return parseConversation(data)
+}
+
+// parseConversation returns the turns of the JSON export.
+// Message IDs are replaced by unique negative ones, so imported turns precede all chat messages.
+func parseConversation(data []byte) ([]turn, error) {
+	conv := &conversation{}
+	if err := json.Unmarshal(data, conv); err != nil {
+		return nil, fmt.Errorf("failed to parse export: %w", err)
+	}
+
+	turns := make([]turn, 0, len(conv.Turns))
+	for i, t := range conv.Turns {
+		if strings.TrimSpace(t.Prompt) == "" || strings.TrimSpace(t.Answer) == "" {
+			return nil, fmt.Errorf("turn %d has empty prompt or answer", i+1)
+		}
+
+		t.MessageID = i - len(conv.Turns)
+		turns = append(turns, t)
+	}
+
+	if len(turns) == 0 {
+		return nil, errEmptyExport
+	}
+
+	return turns, nil
+}
+
+// resetHandler forgets the chat conversation.
+func (b *Bot) resetHandler(c telebot.Context) error {
+	n := b.history.clear(c.Chat().ID)
+	return c.Send(tr(c, i18n.Reset, n))
+}
@@ -0,0 +1,170 @@
+package bot
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+func TestBotExportDocument(t *testing.T) {
+	b, err := New(&config.Config{Offline: true, HistorySize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = b.exportDocument(1, ""); !errors.Is(err, errEmptyExport) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b.history.add(1, turn{
+		MessageID: 5,
+		Time:      time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC),
+		Prompt:    "Кто ты?",
+		Answer:    "Меня зовут Алиса",
+		Tokens:    20,
+		Model:     ygpt.ModelGeneral,
+		Options:   ygpt.GenerationOptions{MaxTokens: ygpt.DefaultMaxTokens},
+	})
+
+	testCases := []struct {
+		format   string
+		fileName string
+		expected []string
+	}{
+		{
+			format:   "",
+			fileName: ".md",
+			expected: []string{"## 2023-10-01T12:00:00Z", "model: general", "tokens: 20", "**User:**\n\nКто ты?"},
+		},
+		{
+			format:   "json",
+			fileName: ".json",
+			expected: []string{`"chat_id": 1`, `"prompt": "Кто ты?"`, `"tokens": 20`, `"maxTokens": 2000`},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.fileName, func(t *testing.T) {
+			doc, e := b.exportDocument(1, tc.format)
+			if e != nil {
+				t.Fatal(e)
+			}
+
+			if !strings.HasSuffix(doc.FileName, tc.fileName) {
+				t.Errorf("unexpected file name: %q", doc.FileName)
+			}
+
+			data, e := io.ReadAll(doc.File.FileReader)
+			if e != nil {
+				t.Fatal(e)
+			}
+
+			for _, expected := range tc.expected {
+				if !strings.Contains(string(data), expected) {
+					t.Errorf("expected %q in %q", expected, data)
+				}
+			}
+		})
+	}
+
+	if _, err = b.exportDocument(1, "pdf"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestParseConversation(t *testing.T) {
+	testCases := []struct {
+		name  string
+		data  string
+		turns int
+		err   bool
+	}{
+		{name: "invalid", data: "{", err: true},
+		{name: "empty", data: `{"turns":[]}`, err: true},
+		{name: "noAnswer", data: `{"turns":[{"prompt":"a","model":"general"}]}`, err: true},
+		{name: "badModel", data: `{"turns":[{"prompt":"a","answer":"b","model":"unknown"}]}`, err: true},
+		{
+			name:  "valid",
+			data:  `{"turns":[{"message_id":7,"prompt":"a","answer":"b","model":"general"},{"prompt":"c","answer":"d","model":"general"}]}`,
+			turns: 2,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			turns, err := parseConversation([]byte(tc.data))
+			if tc.err {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(turns) != tc.turns {
+				t.Fatalf("unexpected turns: %v", turns)
+			}
+
+			for j, tr := range turns {
+				if tr.MessageID != j-len(turns) {
+					t.Errorf("unexpected message ID: %d", tr.MessageID)
+				}
+			}
+		})
+	}
+}
+
+func TestBotImportHandler(t *testing.T) {
+	b, tg := newFixture(t, http.NotFoundHandler(), func(cfg *config.Config) { cfg.HistorySize = 1 })
+	tg.AddFile("test", []byte(`{"turns":[{"prompt":"a","answer":"b","model":"general"},{"prompt":"c","answer":"d","model":"general"}]}`))
+
+	doc := &telebot.Document{File: telebot.File{FileID: "test", FileSize: 100}, FileName: "conversation.json"}
+	message := &telebot.Message{ID: 3, Chat: &telebot.Chat{ID: 1}, Document: doc, Caption: "/import"}
+
+	if err := b.documentHandler(&testContext{update: telebot.Update{Message: message}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// turns over the history size are summarized before the next prompt
+	turns := b.history.turns(1)
+	if len(turns) != 2 || turns[0].MessageID != -2 || turns[1].MessageID != -1 {
+		t.Fatalf("unexpected turns: %v", turns)
+	}
+
+	b.history.fold(1, turns[0].MessageID, "a")
+	if messages := b.history.messages(1, 4); len(messages) != 2 || messages[0].Text != "c" {
+		t.Errorf("unexpected messages: %v", messages)
+	}
+}
+
+func TestBotImportHandlerFailed(t *testing.T) {
+	b, tg := newFixture(t, http.NotFoundHandler(), func(cfg *config.Config) { cfg.HistorySize = 1 })
+	tg.AddFile("test", []byte("{"))
+
+	doc := &telebot.Document{File: telebot.File{FileID: "test", FileSize: 1}, FileName: "conversation.json"}
+	message := &telebot.Message{ID: 3, Chat: &telebot.Chat{ID: 1}, Document: doc, Caption: "/import"}
+	c := &testContext{update: telebot.Update{Message: message}}
+
+	if err := b.documentHandler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	text, _ := c.lastSent().(string)
+	if !strings.HasPrefix(text, i18n.English.T(i18n.ImportFailed, "")) || strings.Contains(text, "JSON") {
+		t.Errorf("unexpected text: %q", text)
+	}
+}
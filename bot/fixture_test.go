@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/telegramtest"
+)
+
+// testToken is a bot token of the fake Telegram Bot API server.
+const testToken = "test-token"
+
+// newFixture returns a bot using the fake Telegram Bot API server and a test Yandex API server with the handler,
+// the options change the test config before the bot is created. The chat generation API is served by the root path,
+// recognition, synthesis, OCR and tokenizer APIs by "/stt", "/tts", "/ocr" and "/tokenize" paths.
+// Both servers are closed by the test cleanup.
+func newFixture(t *testing.T, handler http.Handler, options ...func(cfg *config.Config)) (*Bot, *telegramtest.Server) {
+	t.Helper()
+
+	s := httptest.NewServer(handler)
+	t.Cleanup(s.Close)
+
+	tg := telegramtest.NewServer(testToken)
+	t.Cleanup(tg.Close)
+
+	cfg := &config.Config{
+		Offline:     true,
+		Token:       testToken,
+		TelegramURL: tg.URL,
+		Timeout:     config.TimeDuration{Duration: 5 * time.Second},
+		Chat: config.Chat{
+			APIKey:        "test-key",
+			URL:           s.URL,
+			RecognizeURL:  s.URL + "/stt",
+			SynthesizeURL: s.URL + "/tts",
+			OCRURL:        s.URL + "/ocr",
+			TokenizeURL:   s.URL + "/tokenize",
+			Client:        s.Client(),
+		},
+	}
+
+	for _, option := range options {
+		option(cfg)
+	}
+
+	b, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b, tg
+}
+
+// answerHandler returns a chat generation API handler which answers with the text.
+func answerHandler(t *testing.T, text string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		response := fmt.Sprintf(`{"result":{"message":{"role":"Ассистент","text":%q},"num_tokens":"20"}}`, text)
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+)
+
+// defaultForwardWindow is used if the forwarded messages window is not configured.
+const defaultForwardWindow = 2 * time.Second
+
+// batchKey identifies a batch of forwarded messages from a user in a chat.
+type batchKey struct {
+	chatID int64
+	userID int64
+}
+
+// forwardBatch is a burst of forwarded messages.
+type forwardBatch struct {
+	messages []*telebot.Message
+	last     time.Time // the time when the last message was added
+}
+
+// forwarder collects bursts of forwarded messages.
+type forwarder struct {
+	sync.Mutex
+	window  time.Duration
+	batches map[batchKey]*forwardBatch
+}
+
+// newForwarder returns a new forwarder, a batch is finished
+// if there are no new messages during the window.
+func newForwarder(window time.Duration) *forwarder {
+	if window <= 0 {
+		window = defaultForwardWindow
+	}
+
+	return &forwarder{window: window, batches: make(map[batchKey]*forwardBatch)}
+}
+
+// add adds the message to the batch, it returns true if the message starts a new batch.
+func (f *forwarder) add(key batchKey, m *telebot.Message) bool {
+	f.Lock()
+	defer f.Unlock()
+
+	batch, ok := f.batches[key]
+	if !ok {
+		batch = &forwardBatch{}
+		f.batches[key] = batch
+	}
+
+	batch.messages = append(batch.messages, m)
+	batch.last = time.Now()
+
+	return !ok
+}
+
+// wait blocks until there are no new messages in the batch during the window,
+// then it returns the batch messages in the chat order.
+// Updates are handled concurrently, so the messages can be added in any order.
+func (f *forwarder) wait(key batchKey) []*telebot.Message {
+	for {
+		f.Lock()
+		batch := f.batches[key]
+		delay := f.window - time.Since(batch.last)
+
+		if delay <= 0 {
+			delete(f.batches, key)
+			f.Unlock()
+
+			sort.Slice(batch.messages, func(i, j int) bool { return batch.messages[i].ID < batch.messages[j].ID })
+			return batch.messages
+		}
+
+		f.Unlock()
+		time.Sleep(delay)
+	}
+}
+
+// isForwarded returns true if the message is forwarded.
+// Bot API 7 sets the forward origin, the legacy forward fields are checked too.
+func isForwarded(m *telebot.Message) bool {
+	return m.Origin != nil || m.OriginalUnixtime != 0
+}
+
+// forwardTime returns the original time of the forwarded message.
+func forwardTime(m *telebot.Message) time.Time {
+	if m.Origin != nil {
+		return time.Unix(m.Origin.DateUnixtime, 0)
+	}
+
+	return time.Unix(int64(m.OriginalUnixtime), 0)
+}
+
+// forwardAuthor returns a name of the original author of the forwarded message.
+func forwardAuthor(m *telebot.Message) string {
+	if o := m.Origin; o != nil {
+		switch {
+		case o.Sender != nil:
+			return userName(o.Sender)
+		case o.SenderChat != nil:
+			return chatAuthor(o.SenderChat, o.Signature)
+		case o.Chat != nil:
+			return chatAuthor(o.Chat, o.Signature)
+		case o.SenderUsername != "":
+			return o.SenderUsername
+		}
+	}
+
+	switch {
+	case m.OriginalSender != nil:
+		return userName(m.OriginalSender)
+	case m.OriginalChat != nil:
+		return chatAuthor(m.OriginalChat, m.OriginalSignature)
+	case m.OriginalSenderName != "":
+		return m.OriginalSenderName
+	default:
+		return "unknown"
+	}
+}
+
+// userName returns a full name of the user or its username if the name is empty.
+func userName(u *telebot.User) string {
+	if name := strings.TrimSpace(u.FirstName + " " + u.LastName); name != "" {
+		return name
+	}
+
+	return u.Username
+}
+
+// chatAuthor returns a title of the chat with the author signature if it is set.
+func chatAuthor(chat *telebot.Chat, signature string) string {
+	if signature != "" {
+		return chat.Title + " (" + signature + ")"
+	}
+
+	return chat.Title
+}
+
+// forwardedConversation returns a text of forwarded messages with their authors and timestamps.
+func forwardedConversation(messages []*telebot.Message) string {
+	var b strings.Builder
+
+	for _, m := range messages {
+		ts := forwardTime(m).UTC().Format("2006-01-02 15:04")
+		b.WriteString(fmt.Sprintf("[%s] %s: %s\n", ts, forwardAuthor(m), strings.TrimSpace(m.Text)))
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// forwardHandler collects a burst of forwarded messages from a user and answers with a single summary.
+// A single forwarded message is summarized too, keeping its author and timestamp.
+func (b *Bot) forwardHandler(c telebot.Context) error {
+	key := batchKey{chatID: c.Chat().ID, userID: c.Sender().ID}
+
+	if !b.forwarder.add(key, c.Message()) {
+		// the message is handled by the first message handler of the batch
+		return nil
+	}
+
+	messages := b.forwarder.wait(key)
+	return b.answer(c, prompt{content: forwardedConversation(messages), instruction: tr(c, i18n.ForwardInstruction)})
+}
@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+func TestForwardedConversation(t *testing.T) {
+	messages := []*telebot.Message{
+		{Text: "hi", OriginalUnixtime: 1696154400, OriginalSender: &telebot.User{FirstName: "John", LastName: "Smith"}},
+		{Text: "news", OriginalUnixtime: 1696154460, OriginalChat: &telebot.Chat{Title: "Channel"}},
+		{Text: "secret", OriginalUnixtime: 1696154520, OriginalSenderName: "Hidden"},
+		{Text: "bye", OriginalUnixtime: 1696154580, OriginalSender: &telebot.User{Username: "jane"}},
+		{Text: "origin", Origin: &telebot.MessageOrigin{Type: "user", DateUnixtime: 1696154640, Sender: &telebot.User{FirstName: "Ann"}}},
+		{Text: "anonymous", Origin: &telebot.MessageOrigin{Type: "hidden_user", DateUnixtime: 1696154700, SenderUsername: "Ghost"}},
+		{Text: "admin", Origin: &telebot.MessageOrigin{Type: "chat", DateUnixtime: 1696154760, SenderChat: &telebot.Chat{Title: "Group"}}},
+		{Text: "post", Origin: &telebot.MessageOrigin{Type: "channel", DateUnixtime: 1696154820, Chat: &telebot.Chat{Title: "News"}, Signature: "Editor"}},
+	}
+
+	expected := "[2023-10-01 10:00] John Smith: hi\n" +
+		"[2023-10-01 10:01] Channel: news\n" +
+		"[2023-10-01 10:02] Hidden: secret\n" +
+		"[2023-10-01 10:03] jane: bye\n" +
+		"[2023-10-01 10:04] Ann: origin\n" +
+		"[2023-10-01 10:05] Ghost: anonymous\n" +
+		"[2023-10-01 10:06] Group: admin\n" +
+		"[2023-10-01 10:07] News (Editor): post"
+
+	if s := forwardedConversation(messages); s != expected {
+		t.Errorf("unexpected conversation: %q", s)
+	}
+
+	for i, m := range messages {
+		if !isForwarded(m) {
+			t.Errorf("message %d is not forwarded", i)
+		}
+	}
+}
+
+func TestBotForwardHandler(t *testing.T) {
+	var requests atomic.Int32
+
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		request := &ygpt.TextGenerationChat{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		if request.InstructionText != i18n.English.T(i18n.ForwardInstruction) {
+			t.Errorf("unexpected instruction: %q", request.InstructionText)
+		}
+
+		expected := "[2023-10-01 10:00] John: first\n" +
+			"[2023-10-01 10:00] John: second\n" +
+			"[2023-10-01 10:00] John: third"
+
+		if text := request.Messages[0].Text; text != expected {
+			t.Errorf("unexpected conversation: %q", text)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"result":{"message":{"role":"Ассистент","text":"TL;DR"},"num_tokens":"20"}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}), func(cfg *config.Config) {
+		cfg.ForwardWindow = config.TimeDuration{Duration: 200 * time.Millisecond}
+	})
+
+	var wg sync.WaitGroup
+	author := &telebot.User{FirstName: "John"}
+	ids := []int{3, 1, 2}
+
+	// the last message is handled first, the batch keeps the chat order
+	for i, text := range []string{"third", "first", "second"} {
+		message := &telebot.Message{
+			ID:               ids[i],
+			Chat:             &telebot.Chat{ID: 1},
+			Text:             text,
+			OriginalSender:   author,
+			OriginalUnixtime: 1696154400 + i,
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if e := b.rootHandler(&testContext{update: telebot.Update{Message: message}}); e != nil {
+				t.Error(e)
+			}
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	wg.Wait()
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("unexpected number of generation requests: %d", n)
+	}
+
+	if text := tg.LastText(); text != "TL;DR" {
+		t.Errorf("unexpected answer: %q", text)
+	}
+}
+
+func TestBotForwardHandlerSingle(t *testing.T) {
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &ygpt.TextGenerationChat{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		if request.InstructionText != i18n.English.T(i18n.ForwardInstruction) {
+			t.Errorf("unexpected instruction: %q", request.InstructionText)
+		}
+
+		if text := request.Messages[0].Text; text != "[2023-10-01 10:00] Channel: news" {
+			t.Errorf("unexpected conversation: %q", text)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"result":{"message":{"role":"Ассистент","text":"TL;DR"},"num_tokens":"20"}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}), func(cfg *config.Config) {
+		cfg.ForwardWindow = config.TimeDuration{Duration: 50 * time.Millisecond}
+	})
+
+	// Bot API 7 message has only the forward origin
+	data := `{"message_id":1,"chat":{"id":1},"text":"news",` +
+		`"forward_origin":{"type":"channel","date":1696154400,"chat":{"id":-100,"title":"Channel"},"message_id":7}}`
+
+	message := &telebot.Message{}
+	if err := json.Unmarshal([]byte(data), message); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.rootHandler(&testContext{update: telebot.Update{Message: message}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := tg.LastText(); text != "TL;DR" {
+		t.Errorf("unexpected answer: %q", text)
+	}
+}
@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+// turn is a prompt and its answer in the chat conversation.
+type turn struct {
+	MessageID int                    `json:"message_id"`
+	Time      time.Time              `json:"time"`
+	Prompt    string                 `json:"prompt"`
+	Answer    string                 `json:"answer"`
+	Tokens    int64                  `json:"tokens"`
+	Model     ygpt.Model             `json:"model"`
+	Options   ygpt.GenerationOptions `json:"options"`
+}
+
+// memory is a summary of the earlier chat conversation turns.
+type memory struct {
+	Text    string
+	Turns   int // number of summarized turns
+	Updated time.Time
+}
+
+// history keeps the latest conversation turns of chats and summaries of the earlier ones in memory.
+type history struct {
+	sync.Mutex
+	size     int // maximum number of turns in the conversation context, history is disabled if it is not positive
+	chats    map[int64][]turn
+	memories map[int64]memory
+}
+
+// newHistory returns a new empty history storage.
+func newHistory(size int) *history {
+	return &history{size: size, chats: make(map[int64][]turn), memories: make(map[int64]memory)}
+}
+
+// enabled returns true if the conversation history is kept.
+func (h *history) enabled() bool {
+	return h.size > 0
+}
+
+// add saves the turn to the chat history.
+// A turn of the same prompt message is replaced, it is the case of edited messages.
+// Turns over the history size are summarized into the memory before the next prompt.
+func (h *history) add(chatID int64, t turn) {
+	if !h.enabled() {
+		return
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	turns := h.chats[chatID]
+	i := sort.Search(len(turns), func(i int) bool { return turns[i].MessageID >= t.MessageID })
+
+	if i < len(turns) && turns[i].MessageID == t.MessageID {
+		turns[i] = t
+	} else {
+		turns = append(turns, turn{})
+		copy(turns[i+1:], turns[i:])
+		turns[i] = t
+	}
+
+	h.chats[chatID] = turns
+}
+
+// messages returns the chat conversation before the prompt message as a list of chat messages.
+func (h *history) messages(chatID int64, messageID int) []ygpt.Message {
+	return turnMessages(h.before(chatID, messageID))
+}
+
+// before returns a copy of the chat conversation turns before the prompt message.
+func (h *history) before(chatID int64, messageID int) []turn {
+	h.Lock()
+	defer h.Unlock()
+
+	var turns []turn
+
+	for _, t := range h.chats[chatID] {
+		if t.MessageID >= messageID {
+			break
+		}
+
+		turns = append(turns, t)
+	}
+
+	return turns
+}
+
+// turnMessages returns the turns as a list of chat messages.
+func turnMessages(turns []turn) []ygpt.Message {
+	messages := make([]ygpt.Message, 0, 2*len(turns))
+
+	for _, t := range turns {
+		messages = append(
+			messages,
+			ygpt.Message{Role: ygpt.RoleUser, Text: t.Prompt},
+			ygpt.Message{Role: ygpt.RoleAssistant, Text: t.Answer},
+		)
+	}
+
+	return messages
+}
+
+// memory returns the summary of the earlier chat conversation.
+func (h *history) memory(chatID int64) (memory, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	m, ok := h.memories[chatID]
+	return m, ok
+}
+
+// fold replaces the chat turns up to the message inclusive by the updated memory text.
+func (h *history) fold(chatID int64, messageID int, text string) {
+	h.Lock()
+	defer h.Unlock()
+
+	i := h.cut(chatID, messageID)
+
+	m := h.memories[chatID]
+	m.Text, m.Turns, m.Updated = text, m.Turns+i, time.Now().UTC()
+
+	h.memories[chatID] = m
+}
+
+// drop removes the chat turns up to the message inclusive without summarizing them.
+func (h *history) drop(chatID int64, messageID int) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.cut(chatID, messageID)
+}
+
+// cut removes the chat turns up to the message inclusive and returns their number, the lock must be held.
+func (h *history) cut(chatID int64, messageID int) int {
+	turns := h.chats[chatID]
+	i := sort.Search(len(turns), func(i int) bool { return turns[i].MessageID > messageID })
+
+	h.chats[chatID] = append([]turn(nil), turns[i:]...)
+	return i
+}
+
+// forget removes the summary of the earlier chat conversation, it returns false if there is no one.
+func (h *history) forget(chatID int64) bool {
+	h.Lock()
+	defer h.Unlock()
+
+	_, ok := h.memories[chatID]
+	delete(h.memories, chatID)
+
+	return ok
+}
+
+// turns returns a copy of the chat conversation.
+func (h *history) turns(chatID int64) []turn {
+	h.Lock()
+	defer h.Unlock()
+
+	return append([]turn(nil), h.chats[chatID]...)
+}
+
+// replace sets the chat conversation and removes the memory.
+// Turns must be sorted by unique message IDs, the ones over the history size are summarized before the next prompt.
+func (h *history) replace(chatID int64, turns []turn) {
+	turns = append([]turn(nil), turns...)
+
+	h.Lock()
+	defer h.Unlock()
+
+	h.chats[chatID] = turns
+	delete(h.memories, chatID)
+}
+
+// clear forgets the chat conversation with its memory and returns the number of removed turns.
+func (h *history) clear(chatID int64) int {
+	h.Lock()
+	defer h.Unlock()
+
+	n := len(h.chats[chatID])
+	delete(h.chats, chatID)
+	delete(h.memories, chatID)
+
+	return n
+}
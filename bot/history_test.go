@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+func TestHistory(t *testing.T) {
+	h := newHistory(2)
+
+	h.add(1, turn{MessageID: 3, Prompt: "c", Answer: "C"})
+	h.add(1, turn{MessageID: 1, Prompt: "a", Answer: "A"})
+	h.add(1, turn{MessageID: 2, Prompt: "b", Answer: "B"})
+	h.add(2, turn{MessageID: 1, Prompt: "x", Answer: "X"})
+
+	// turns over the history size are kept until they are summarized
+	turns := h.turns(1)
+	if n := len(turns); n != 3 {
+		t.Fatalf("unexpected turns number: %d", n)
+	}
+
+	if turns[0].MessageID != 1 || turns[1].MessageID != 2 || turns[2].MessageID != 3 {
+		t.Errorf("unexpected turns order: %v", turns)
+	}
+
+	h.drop(1, 1)
+	if _, ok := h.memory(1); ok {
+		t.Error("dropped turns are summarized")
+	}
+
+	// edited message replaces its turn
+	h.add(1, turn{MessageID: 3, Prompt: "cc", Answer: "CC"})
+
+	messages := h.messages(1, 10)
+	expected := []ygpt.Message{
+		{Role: ygpt.RoleUser, Text: "b"},
+		{Role: ygpt.RoleAssistant, Text: "B"},
+		{Role: ygpt.RoleUser, Text: "cc"},
+		{Role: ygpt.RoleAssistant, Text: "CC"},
+	}
+
+	if len(messages) != len(expected) {
+		t.Fatalf("unexpected messages: %v", messages)
+	}
+
+	for i, m := range messages {
+		if m != expected[i] {
+			t.Errorf("unexpected message %d: %v", i, m)
+		}
+	}
+
+	// only previous turns are the context of the message
+	if messages = h.messages(1, 3); len(messages) != 2 {
+		t.Errorf("unexpected messages: %v", messages)
+	}
+
+	if n := h.clear(1); n != 2 {
+		t.Errorf("unexpected cleared turns: %d", n)
+	}
+
+	if n := len(h.turns(2)); n != 1 {
+		t.Errorf("unexpected other chat turns: %d", n)
+	}
+
+	disabled := newHistory(0)
+	disabled.add(1, turn{MessageID: 1, Prompt: "a", Answer: "A"})
+
+	if n := len(disabled.turns(1)); n != 0 {
+		t.Errorf("unexpected disabled history turns: %d", n)
+	}
+}
+
+func TestBotAnswerHistory(t *testing.T) {
+	var lastMessages int
+
+	b, _ := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &ygpt.TextGenerationChat{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+		lastMessages = len(request.Messages)
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"result":{"message":{"role":"Ассистент","text":"answer"},"num_tokens":"20"}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}), func(cfg *config.Config) {
+		cfg.HistorySize = 10
+	})
+
+	for i, expected := range []int{1, 3, 5} {
+		message := &telebot.Message{ID: i + 1, Text: "question", Chat: &telebot.Chat{ID: 1}}
+		if err := b.rootHandler(&testContext{update: telebot.Update{Message: message}}); err != nil {
+			t.Fatal(err)
+		}
+
+		if lastMessages != expected {
+			t.Errorf("unexpected request messages number: %d, expected %d", lastMessages, expected)
+		}
+	}
+
+	turns := b.history.turns(1)
+	if n := len(turns); n != 3 {
+		t.Fatalf("unexpected turns number: %d", n)
+	}
+
+	if tr := turns[2]; tr.Tokens != 20 || tr.Answer != "answer" || tr.Options.MaxTokens != ygpt.DefaultMaxTokens {
+		t.Errorf("unexpected turn: %+v", tr)
+	}
+}
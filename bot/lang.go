@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"strings"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+)
+
+// langKey is a handler context key of the user's language.
+const langKey = "lang"
+
+// langMiddleware injects the chat language into the handler context,
+// it is set by /lang command or detected by the Telegram user's language.
+func (b *Bot) langMiddleware() telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			if chat := c.Chat(); chat != nil {
+				if l := b.settings.get(chat.ID).lang; l != "" {
+					c.Set(langKey, l)
+					return next(c)
+				}
+			}
+
+			c.Set(langKey, senderLang(c))
+			return next(c)
+		}
+	}
+}
+
+// senderLang returns a supported language of the Telegram user or the default one.
+func senderLang(c telebot.Context) i18n.Lang {
+	if user := c.Sender(); user != nil {
+		if l, ok := i18n.Parse(user.LanguageCode); ok {
+			return l
+		}
+	}
+
+	return i18n.Default
+}
+
+// lang returns the language of the handler context.
+func lang(c telebot.Context) i18n.Lang {
+	if l, ok := c.Get(langKey).(i18n.Lang); ok {
+		return l
+	}
+
+	return senderLang(c)
+}
+
+// tr returns the localized message of the handler context language.
+func tr(c telebot.Context, key i18n.Key, args ...any) string {
+	return lang(c).T(key, args...)
+}
+
+// langHandler shows or changes the chat language, "auto" uses the Telegram user's language.
+func (b *Bot) langHandler(c telebot.Context) error {
+	var (
+		chatID  = c.Chat().ID
+		payload = strings.ToLower(strings.TrimSpace(c.Message().Payload))
+	)
+
+	switch payload {
+	case "":
+		return c.Send(tr(c, i18n.LangCurrent, lang(c)))
+	case "auto":
+		b.settings.update(chatID, func(cs *chatSettings) { cs.lang = "" })
+		c.Set(langKey, senderLang(c))
+	default:
+		l, ok := i18n.Parse(payload)
+		if !ok {
+			return c.Send(tr(c, i18n.LangUsage))
+		}
+
+		b.settings.update(chatID, func(cs *chatSettings) { cs.lang = l })
+		c.Set(langKey, l)
+	}
+
+	return c.Send(tr(c, i18n.LangCurrent, lang(c)))
+}
@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+// langContext is a test context with the sender's language code.
+type langContext struct {
+	*testContext
+	code string
+}
+
+func (m *langContext) Sender() *telebot.User {
+	return &telebot.User{ID: 1, Username: "test", LanguageCode: m.code}
+}
+
+func TestLangMiddleware(t *testing.T) {
+	b := newAdminBot(t)
+
+	testCases := []struct {
+		name     string
+		code     string
+		override i18n.Lang
+		expected i18n.Lang
+	}{
+		{name: "russian", code: "ru", expected: i18n.Russian},
+		{name: "region", code: "en-GB", expected: i18n.English},
+		{name: "unsupported", code: "de", expected: i18n.Default},
+		{name: "override", code: "en", override: i18n.Russian, expected: i18n.Russian},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			b.settings.update(1, func(cs *chatSettings) { cs.lang = tc.override })
+
+			var result i18n.Lang
+			handler := b.langMiddleware()(func(c telebot.Context) error {
+				result = lang(c)
+				return nil
+			})
+
+			if err := handler(&langContext{testContext: &testContext{}, code: tc.code}); err != nil {
+				t.Fatal(err)
+			}
+
+			if result != tc.expected {
+				t.Errorf("unexpected language: %q", result)
+			}
+		})
+	}
+}
+
+func TestLangHandler(t *testing.T) {
+	b := newAdminBot(t)
+
+	testCases := []struct {
+		name     string
+		command  string
+		expected string
+		lang     i18n.Lang
+	}{
+		{name: "current", command: "/lang", expected: "Language: en.", lang: ""},
+		{name: "russian", command: "/lang ru", expected: "Язык: ru.", lang: i18n.Russian},
+		{name: "bad", command: "/lang fr", expected: "Использование: /lang [en|ru|auto]", lang: i18n.Russian},
+		{name: "auto", command: "/lang auto", expected: "Language: en.", lang: ""},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			c := commandContext(tc.command)
+			handler := b.langMiddleware()(b.langHandler)
+
+			if err := handler(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if text := c.lastSent(); text != tc.expected {
+				t.Errorf("unexpected reply: %v", text)
+			}
+
+			if l := b.settings.get(1).lang; l != tc.lang {
+				t.Errorf("unexpected chat language: %q", l)
+			}
+		})
+	}
+}
+
+func TestDefaultInstruction(t *testing.T) {
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &ygpt.TextGenerationChat{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		if request.InstructionText != i18n.Russian.T(i18n.DefaultInstruction) {
+			t.Errorf("unexpected instruction: %q", request.InstructionText)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"result":{"message":{"role":"Ассистент","text":"Меня зовут Алиса"},"num_tokens":"20"}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	handler := b.langMiddleware()(b.rootHandler)
+	if err := handler(&langContext{testContext: &testContext{}, code: "ru"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sent, edited := len(tg.Calls("sendMessage")), len(tg.Calls("editMessageText"))
+	if text := tg.LastText(); sent != 1 || edited != 1 || text != "Меня зовут Алиса" {
+		t.Errorf("unexpected messages: %d, %d, %q", sent, edited, text)
+	}
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"log/slog"
+
+	"gopkg.in/telebot.v3"
+)
+
+// loggerKey is a handler context key of the request-scoped logger.
+const loggerKey = "logger"
+
+// loggerMiddleware injects a logger with message, user and chat IDs into the handler context.
+func loggerMiddleware() telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			c.Set(loggerKey, newLogger(c))
+			return next(c)
+		}
+	}
+}
+
+// newLogger returns a logger with the update's message, user and chat IDs.
+func newLogger(c telebot.Context) *slog.Logger {
+	var args []any
+
+	if m := c.Message(); m != nil {
+		args = append(args, "id", m.ID)
+	}
+
+	if user := c.Sender(); user != nil {
+		args = append(args, "user_id", user.ID)
+	}
+
+	if chat := c.Chat(); chat != nil {
+		args = append(args, "chat_id", chat.ID)
+	}
+
+	return slog.Default().With(args...)
+}
+
+// logger returns the request-scoped logger of the handler context.
+func logger(c telebot.Context) *slog.Logger {
+	if l, ok := c.Get(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+
+	return newLogger(c)
+}
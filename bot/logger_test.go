@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"gopkg.in/telebot.v3"
+)
+
+func TestLoggerMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	c := &testContext{}
+	handler := loggerMiddleware()(func(c telebot.Context) error {
+		logger(c).Info("test")
+		return nil
+	})
+
+	if err := handler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.Get(loggerKey).(*slog.Logger); !ok {
+		t.Fatal("logger is not set")
+	}
+
+	record := make(map[string]any)
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]any{"msg": "test", "id": 2.0, "user_id": 1.0, "chat_id": 1.0}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Errorf("unexpected %q: %v", key, record[key])
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/tracing"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+// Summarization errors.
+var (
+	errEmptySummary  = errors.New("empty summary")
+	errSummaryBudget = errors.New("summarized turn exceeds the input tokens budget")
+)
+
+// memoryMessage returns the leading history message with the summary of the earlier conversation,
+// it is a labeled user message, so the model does not treat it as its own earlier reply.
+func memoryMessage(c telebot.Context, text string) ygpt.Message {
+	return ygpt.Message{Role: ygpt.RoleUser, Text: tr(c, i18n.MemoryContext, text)}
+}
+
+// summaryText returns a text of the summarization request: the previous summary with the turns.
+func summaryText(prev string, turns []turn) string {
+	var text strings.Builder
+	if prev != "" {
+		fmt.Fprintf(&text, "Summary:\n%s\n\n", prev)
+	}
+
+	text.WriteString("Conversation:\n")
+	for _, t := range turns {
+		text.WriteString(turnText(t))
+	}
+
+	return text.String()
+}
+
+// turnText returns a text of the conversation turn in the summarization request.
+func turnText(t turn) string {
+	return fmt.Sprintf("User: %s\nAssistant: %s\n", t.Prompt, t.Answer)
+}
+
+// summarize returns an updated summary of the earlier conversation: the previous one with the turns.
+// The turns are summarized in batches fitting the input tokens budget, every batch summary is the previous one
+// of the next batch; it fails if a turn with the previous summary alone exceeds the budget.
+func (b *Bot) summarize(ctx context.Context, c telebot.Context, key msgKey, prev string, turns []turn) (string, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "history.summarize")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("history.turns", len(turns)), attribute.Bool("history.memory", prev != ""))
+
+	var (
+		instruction = tr(c, i18n.MemoryInstruction)
+		budget      = b.cfg.Chat.InputBudget() - b.tokens.Count(ctx, instruction)
+		batches     int
+	)
+
+	for len(turns) > 0 {
+		n := b.summaryBatch(ctx, prev, turns, budget)
+		if n == 0 {
+			tracing.Fail(span, errSummaryBudget)
+			return "", errSummaryBudget
+		}
+
+		summary, err := b.summarizeBatch(ctx, c, key, instruction, prev, turns[:n])
+		if err != nil {
+			tracing.Fail(span, err)
+			return "", err
+		}
+
+		prev, turns = summary, turns[n:]
+		batches++
+	}
+
+	span.SetAttributes(attribute.Int("history.batches", batches))
+	return prev, nil
+}
+
+// summaryBatch returns a number of the first turns fitting the tokens budget with the previous summary.
+func (b *Bot) summaryBatch(ctx context.Context, prev string, turns []turn, budget int64) int {
+	total := b.tokens.Count(ctx, summaryText(prev, nil))
+
+	for i, t := range turns {
+		if total += b.tokens.Count(ctx, turnText(t)); total > budget {
+			return i
+		}
+	}
+
+	return len(turns)
+}
+
+// summarizeBatch returns a summary of the previous one with the turns.
+func (b *Bot) summarizeBatch(ctx context.Context, c telebot.Context, key msgKey, instruction, prev string, turns []turn) (string, error) {
+	var (
+		prompt = &config.Prompt{Text: summaryText(prev, turns), Instruction: instruction}
+		start  = time.Now()
+	)
+
+	answer, err := b.generate(ctx, c, prompt, key.messageID)
+	b.auditLog(c, key, prompt.Text, answer, err, start)
+	b.countRequest(ctx, answer, err)
+
+	if err != nil {
+		return "", err
+	}
+
+	summary := strings.TrimSpace(answer.Text)
+	if summary == "" {
+		return "", errEmptySummary
+	}
+
+	logger(c).Info("history is summarized", "turns", len(turns), "tokens", answer.Tokens)
+	return summary, nil
+}
+
+// memoryHandler shows or forgets the summary of the earlier chat conversation.
+func (b *Bot) memoryHandler(c telebot.Context) error {
+	var chatID = c.Chat().ID
+
+	if !b.history.enabled() {
+		return c.Send(tr(c, i18n.HistoryDisabled))
+	}
+
+	switch arg := strings.TrimSpace(c.Message().Payload); arg {
+	case "clear":
+		if !b.history.forget(chatID) {
+			return c.Send(tr(c, i18n.MemoryNone))
+		}
+		return c.Send(tr(c, i18n.MemoryForgotten))
+	case "":
+	default:
+		return c.Send(tr(c, i18n.MemoryUsage))
+	}
+
+	m, ok := b.history.memory(chatID)
+	if !ok {
+		return c.Send(tr(c, i18n.MemoryNone))
+	}
+
+	return c.Send(tr(c, i18n.MemoryInfo, m.Turns, m.Updated.Format(time.RFC3339), m.Text))
+}
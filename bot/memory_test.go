@@ -0,0 +1,337 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+func TestFitBudgetMemory(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		summaries []string // texts of summarization requests
+	)
+
+	b, _ := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &ygpt.TextGenerationChat{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		if request.InstructionText != i18n.English.T(i18n.MemoryInstruction) {
+			t.Errorf("unexpected instruction: %q", request.InstructionText)
+		}
+
+		mu.Lock()
+		summaries = append(summaries, request.Messages[len(request.Messages)-1].Text)
+		n := len(summaries)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		response := fmt.Sprintf(`{"result":{"message":{"role":"Ассистент","text":" summary %d "},"num_tokens":"20"}}`, n)
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}), func(cfg *config.Config) {
+		cfg.HistorySize = 10
+		cfg.Chat.ContextTokens, cfg.Chat.MaxTokens = 140, 20
+	})
+
+	var (
+		ctx  = context.Background()
+		c    = &testContext{}
+		text = strings.Repeat("word ", 10) // 10 tokens
+	)
+
+	for i := 1; i <= 6; i++ {
+		b.history.add(1, turn{MessageID: i, Prompt: fmt.Sprintf("p%d %s", i, text), Answer: text})
+	}
+
+	// 6 turns of 21 tokens and the input exceed the budget of 120 tokens
+	request := &config.Prompt{Text: "hi all"}
+	if input, budget := b.fitBudget(ctx, c, msgKey{chatID: 1, messageID: 7}, request, b.history.before(1, 7)); input != 2 || budget != 120 {
+		t.Fatalf("unexpected input and budget: %d, %d", input, budget)
+	}
+
+	if n := len(request.History); n != 11 || !strings.HasPrefix(request.History[1].Text, "p2 ") {
+		t.Fatalf("unexpected history: %v", request.History)
+	}
+
+	if m := request.History[0]; m.Role != ygpt.RoleUser || m.Text != i18n.English.T(i18n.MemoryContext, "summary 1") {
+		t.Errorf("unexpected memory message: %v", m)
+	}
+
+	if request.Instruction != "" {
+		t.Errorf("unexpected instruction: %q", request.Instruction)
+	}
+
+	if turns := b.history.turns(1); len(turns) != 5 || turns[0].MessageID != 2 {
+		t.Errorf("summarized turns are kept: %v", turns)
+	}
+
+	// the next turn overflows the budget again, the memory is updated
+	b.history.add(1, turn{MessageID: 7, Prompt: "p7 " + text, Answer: text})
+
+	request = &config.Prompt{Text: "hi all"}
+	b.fitBudget(ctx, c, msgKey{chatID: 1, messageID: 8}, request, b.history.before(1, 8))
+
+	mu.Lock()
+	if n := len(summaries); n != 2 || !strings.Contains(summaries[1], "Summary:\nsummary 1\n") || !strings.Contains(summaries[1], "User: p2 ") {
+		t.Errorf("unexpected summarization requests: %q", summaries)
+	}
+	mu.Unlock()
+
+	m, ok := b.history.memory(1)
+	if !ok || m.Text != "summary 2" || m.Turns != 2 {
+		t.Errorf("unexpected memory: %v", m)
+	}
+}
+
+func TestFitBudgetHistorySize(t *testing.T) {
+	var fail atomic.Bool
+
+	b, _ := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		answerHandler(t, "summary")(w, r)
+	}), func(cfg *config.Config) {
+		cfg.HistorySize = 2
+	})
+
+	var (
+		ctx = context.Background()
+		c   = &testContext{}
+	)
+
+	for i := 1; i <= 3; i++ {
+		b.history.add(1, turn{MessageID: i, Prompt: fmt.Sprintf("p%d", i), Answer: "a"})
+	}
+
+	// the budget is not exceeded, but the oldest turn is over the history size
+	request := &config.Prompt{Text: "hi", Instruction: "be short"}
+	b.fitBudget(ctx, c, msgKey{chatID: 1, messageID: 4}, request, b.history.before(1, 4))
+
+	if n := len(request.History); n != 5 || request.History[1].Text != "p2" {
+		t.Errorf("unexpected history: %v", request.History)
+	}
+
+	if text := request.History[0].Text; text != i18n.English.T(i18n.MemoryContext, "summary") {
+		t.Errorf("unexpected memory message: %q", text)
+	}
+
+	if text := request.Instruction; text != "be short" {
+		t.Errorf("unexpected instruction: %q", text)
+	}
+
+	if m, ok := b.history.memory(1); !ok || m.Turns != 1 {
+		t.Errorf("unexpected memory: %v", m)
+	}
+
+	// turns over the history size are forgotten if the summarization fails
+	fail.Store(true)
+	b.history.add(1, turn{MessageID: 4, Prompt: "p4", Answer: "a"})
+
+	request = &config.Prompt{Text: "hi"}
+	b.fitBudget(ctx, c, msgKey{chatID: 1, messageID: 5}, request, b.history.before(1, 5))
+
+	if n := len(request.History); n != 5 || request.History[1].Text != "p3" {
+		t.Errorf("unexpected history: %v", request.History)
+	}
+
+	if turns := b.history.turns(1); len(turns) != 2 || turns[0].MessageID != 3 {
+		t.Errorf("unexpected turns: %v", turns)
+	}
+
+	if m, _ := b.history.memory(1); m.Turns != 1 {
+		t.Errorf("memory is changed: %v", m)
+	}
+
+	// summarization requests are counted and show the typing action as other generations
+	if stats := b.stats.snapshot(); stats.Requests != 2 || stats.Errors != 1 || c.notified.Load() != 2 {
+		t.Errorf("unexpected stats: %+v, typing actions %d", stats, c.notified.Load())
+	}
+}
+
+func TestFitBudgetSummaryFailed(t *testing.T) {
+	var calls atomic.Int32
+
+	b, _ := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}), func(cfg *config.Config) {
+		cfg.HistorySize = 10
+		cfg.Chat.ContextTokens, cfg.Chat.MaxTokens = 140, 20
+	})
+
+	var (
+		ctx  = context.Background()
+		c    = &testContext{}
+		text = strings.Repeat("word ", 10) // 10 tokens
+	)
+
+	for i := 1; i <= 6; i++ {
+		b.history.add(1, turn{MessageID: i, Prompt: fmt.Sprintf("p%d %s", i, text), Answer: text})
+	}
+
+	// the turns over the budget are forgotten, so the summarization is not repeated on the next prompt
+	for i := 0; i < 2; i++ {
+		request := &config.Prompt{Text: "hi all"}
+		b.fitBudget(ctx, c, msgKey{chatID: 1, messageID: 7 + i}, request, b.history.before(1, 7+i))
+
+		if n := len(request.History); n != 10 || !strings.HasPrefix(request.History[0].Text, "p2 ") {
+			t.Errorf("unexpected history: %v", request.History)
+		}
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("unexpected summarization requests: %d", n)
+	}
+
+	if turns := b.history.turns(1); len(turns) != 5 || turns[0].MessageID != 2 {
+		t.Errorf("unexpected turns: %v", turns)
+	}
+
+	if _, ok := b.history.memory(1); ok {
+		t.Error("memory is created")
+	}
+}
+
+func TestSummarizeBatches(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		summaries []string // texts of summarization requests
+	)
+
+	b, _ := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &ygpt.TextGenerationChat{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		mu.Lock()
+		summaries = append(summaries, request.Messages[len(request.Messages)-1].Text)
+		n := len(summaries)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		response := fmt.Sprintf(`{"result":{"message":{"role":"Ассистент","text":"summary %d"},"num_tokens":"20"}}`, n)
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}), func(cfg *config.Config) {
+		cfg.Chat.ContextTokens, cfg.Chat.MaxTokens = 140, 20
+	})
+
+	var (
+		ctx    = context.Background()
+		c      = &testContext{}
+		key    = msgKey{chatID: 1, messageID: 10}
+		text   = strings.Repeat("word ", 10) // 10 tokens
+		budget = b.cfg.Chat.InputBudget() - b.tokens.Count(ctx, i18n.English.T(i18n.MemoryInstruction))
+		turns  = make([]turn, 6)
+	)
+
+	for i := range turns {
+		turns[i] = turn{MessageID: i + 1, Prompt: fmt.Sprintf("p%d %s", i+1, text), Answer: text}
+	}
+
+	summary, err := b.summarize(ctx, c, key, "earlier", turns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	requests := append([]string(nil), summaries...)
+	mu.Unlock()
+
+	if n := len(requests); n < 2 || summary != fmt.Sprintf("summary %d", n) {
+		t.Fatalf("unexpected summary %q of %d requests", summary, n)
+	}
+
+	for i, request := range requests {
+		prev := "earlier"
+		if i > 0 {
+			prev = fmt.Sprintf("summary %d", i)
+		}
+
+		if !strings.HasPrefix(request, "Summary:\n"+prev+"\n") || b.tokens.Count(ctx, request) > budget {
+			t.Errorf("unexpected request %d: %q", i, request)
+		}
+	}
+
+	if last := requests[len(requests)-1]; !strings.Contains(last, "User: p6 ") {
+		t.Errorf("the last turn is not summarized: %q", last)
+	}
+
+	// a turn alone exceeds the budget
+	turns = []turn{{MessageID: 1, Prompt: strings.Repeat("word ", 100), Answer: "a"}}
+	if _, err = b.summarize(ctx, c, key, "", turns); !errors.Is(err, errSummaryBudget) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	if n := len(summaries); n != len(requests) {
+		t.Errorf("unexpected requests: %d", n)
+	}
+	mu.Unlock()
+}
+
+func TestMemoryHandler(t *testing.T) {
+	b := newAdminBot(t)
+	b.history = newHistory(10)
+
+	b.history.add(1, turn{MessageID: 1, Prompt: "hello", Answer: "hi"})
+	b.history.add(1, turn{MessageID: 2, Prompt: "how are you", Answer: "fine"})
+	b.history.fold(1, 1, "greetings")
+
+	m, _ := b.history.memory(1)
+	testCases := []struct {
+		command  string
+		expected string
+	}{
+		{command: "/memory", expected: i18n.English.T(i18n.MemoryInfo, 1, m.Updated.Format(time.RFC3339), "greetings")},
+		{command: "/memory all", expected: i18n.English.T(i18n.MemoryUsage)},
+		{command: "/memory clear", expected: i18n.English.T(i18n.MemoryForgotten)},
+		{command: "/memory", expected: i18n.English.T(i18n.MemoryNone)},
+		{command: "/memory clear", expected: i18n.English.T(i18n.MemoryNone)},
+	}
+
+	for _, tc := range testCases {
+		c := commandContext(tc.command)
+		if err := b.memoryHandler(c); err != nil {
+			t.Fatal(err)
+		}
+
+		if text := c.lastSent(); text != tc.expected {
+			t.Errorf("unexpected reply of %q: %v", tc.command, text)
+		}
+	}
+
+	if turns := b.history.turns(1); len(turns) != 1 || turns[0].MessageID != 2 {
+		t.Errorf("unexpected turns: %v", turns)
+	}
+
+	b.history.fold(1, 2, "all")
+	if n := b.history.clear(1); n != 0 {
+		t.Errorf("unexpected cleared turns: %d", n)
+	}
+
+	if _, ok := b.history.memory(1); ok {
+		t.Error("memory is not cleared")
+	}
+}
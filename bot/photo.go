@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/vision"
+)
+
+// errImageTooLarge is an error if the downloaded photo exceeds the text recognition limit.
+var errImageTooLarge = errors.New("image is too large")
+
+// photoHandler recognizes a text on the photo and handles it as a prompt,
+// the photo caption is used as an instruction.
+func (b *Bot) photoHandler(c telebot.Context) error {
+	var (
+		message = c.Message()
+		key     = newMsgKey(message)
+		photo   = message.Photo // telebot keeps the largest photo size
+		text    string
+	)
+
+	if photo.FileSize > vision.MaxImageSize {
+		return c.Send(tr(c, i18n.PhotoTooLarge))
+	}
+
+	err := b.tracked(c, key, func(ctx context.Context) (err error) {
+		text, err = b.recognizeText(ctx, message.ID, &photo.File)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return b.cancelled(c, key, err)
+		}
+
+		if errors.Is(err, errImageTooLarge) {
+			return c.Send(tr(c, i18n.PhotoTooLarge))
+		}
+		return c.Send(tr(c, i18n.PhotoFailed, userError(c, err)))
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return c.Send(tr(c, i18n.PhotoNoText))
+	}
+
+	instruction := strings.TrimSpace(message.Caption)
+	if instruction == "" {
+		instruction = tr(c, i18n.PhotoInstruction)
+	}
+
+	return b.answer(c, prompt{content: text, instruction: instruction})
+}
+
+// recognizeText downloads the photo from Telegram and returns its recognized text.
+func (b *Bot) recognizeText(ctx context.Context, messageID int, file *telebot.File) (string, error) {
+	reader, err := b.bot.File(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to download file: %w", err)
+	}
+
+	defer func() {
+		if e := reader.Close(); e != nil {
+			slog.Error("failed to close file", "id", messageID, "error", e)
+		}
+	}()
+
+	// file size can be unknown, so read one more byte to check the limit
+	image, err := io.ReadAll(io.LimitReader(reader, vision.MaxImageSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if len(image) > vision.MaxImageSize {
+		return "", errImageTooLarge
+	}
+
+	return b.cfg.Chat.TextRecognition(ctx, image, messageID)
+}
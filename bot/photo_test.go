@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/vision"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+func TestBotPhotoHandler(t *testing.T) {
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var response string
+		switch r.URL.Path {
+		case "/ocr":
+			response = `{"result":{"textAnnotation":{"fullText":"panic: assignment to entry in nil map"}}}`
+		default:
+			request := &ygpt.TextGenerationChat{}
+			if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+				t.Error(err)
+			}
+
+			if request.InstructionText != "explain this error" {
+				t.Errorf("unexpected instruction: %q", request.InstructionText)
+			}
+
+			if text := request.Messages[0].Text; text != "panic: assignment to entry in nil map" {
+				t.Errorf("unexpected text: %q", text)
+			}
+
+			response = `{"result":{"message":{"role":"Ассистент","text":"Initialize the map"},"num_tokens":"20"}}`
+		}
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	tg.AddFile("test", []byte("JPEG"))
+
+	photo := &telebot.Photo{File: telebot.File{FileID: "test", FileSize: 4}}
+	message := &telebot.Message{ID: 2, Chat: &telebot.Chat{ID: 1}, Photo: photo, Caption: "explain this error"}
+
+	if err := b.photoHandler(&testContext{update: telebot.Update{Message: message}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := tg.LastText(); text != "Initialize the map" {
+		t.Errorf("unexpected answer: %q", text)
+	}
+}
+
+func TestBotPhotoHandlerTooLarge(t *testing.T) {
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s", r.URL.Path)
+	}))
+
+	// the photo size is unknown before the download
+	tg.AddFile("test", make([]byte, vision.MaxImageSize+1))
+
+	photo := &telebot.Photo{File: telebot.File{FileID: "test"}}
+	message := &telebot.Message{ID: 2, Chat: &telebot.Chat{ID: 1}, Photo: photo}
+	c := &testContext{update: telebot.Update{Message: message}}
+
+	if err := b.photoHandler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := c.lastSent(); text != i18n.English.T(i18n.PhotoTooLarge) {
+		t.Errorf("unexpected reply: %v", text)
+	}
+}
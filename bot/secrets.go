@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/secrets"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+// confirmationTTL is a maximum time to wait for the user's decision about a prompt with secrets.
+const confirmationTTL = 10 * time.Minute
+
+// Inline buttons of the prompt with secrets confirmation.
+var (
+	btnSecretsSend   = telebot.InlineButton{Unique: "secrets_send", Text: "Send as is"}
+	btnSecretsMask   = telebot.InlineButton{Unique: "secrets_mask", Text: "Mask and send"}
+	btnSecretsCancel = telebot.InlineButton{Unique: "secrets_cancel", Text: "Cancel"}
+)
+
+// confirmation is a prompt with secrets waiting for the user's decision.
+type confirmation struct {
+	c       telebot.Context // handler context of the prompt message
+	p       prompt
+	userID  int64
+	created time.Time
+}
+
+// confirmations keeps prompts waiting for the user's decision.
+type confirmations struct {
+	sync.Mutex
+	prompts map[msgKey]confirmation
+	now     func() time.Time
+}
+
+// newConfirmations returns a new empty confirmations storage.
+func newConfirmations() *confirmations {
+	return &confirmations{prompts: make(map[msgKey]confirmation), now: time.Now}
+}
+
+// add saves the prompt confirmation replacing the previous one of the same message, expired items are removed.
+func (cs *confirmations) add(key msgKey, item confirmation) {
+	cs.Lock()
+	defer cs.Unlock()
+
+	now := cs.now()
+	for k, v := range cs.prompts {
+		if now.Sub(v.created) > confirmationTTL {
+			delete(cs.prompts, k)
+		}
+	}
+
+	item.created = now
+	cs.prompts[key] = item
+}
+
+// take removes and returns the prompt confirmation if it is not expired and belongs to the user.
+func (cs *confirmations) take(key msgKey, userID int64) (confirmation, bool) {
+	cs.Lock()
+	defer cs.Unlock()
+
+	item, ok := cs.prompts[key]
+	if !ok || item.userID != userID {
+		return confirmation{}, false
+	}
+
+	delete(cs.prompts, key)
+	return item, cs.now().Sub(item.created) <= confirmationTTL
+}
+
+// checkSecrets applies the chat secrets policy to the prompt content,
+// the returned flag is true if the prompt waits for the user's confirmation.
+func (b *Bot) checkSecrets(c telebot.Context, key msgKey, p prompt) (prompt, bool, error) {
+	if p.confirmed {
+		return p, false, nil
+	}
+
+	switch b.cfg.Secrets.ChatPolicy(key.chatID) {
+	case config.SecretsMask:
+		p.content = maskSecrets(c, "prompt", p.content)
+	case config.SecretsConfirm:
+		if findings := secrets.Scan(p.content); len(findings) > 0 {
+			return p, true, b.askConfirmation(c, key, p, findings)
+		}
+	}
+
+	return p, false, nil
+}
+
+// maskInstruction masks secrets of the instruction, for example an uploaded document,
+// if the chat policy is not "off", there is no confirmation of instructions.
+func (b *Bot) maskInstruction(c telebot.Context, key msgKey, instruction string) string {
+	if b.cfg.Secrets.ChatPolicy(key.chatID) == config.SecretsOff {
+		return instruction
+	}
+
+	return maskSecrets(c, "instruction", instruction)
+}
+
+// maskTurns masks secrets of the imported turns if the chat policy is not "off",
+// there is no confirmation of imported turns.
+func (b *Bot) maskTurns(c telebot.Context, chatID int64, turns []turn) {
+	if b.cfg.Secrets.ChatPolicy(chatID) == config.SecretsOff {
+		return
+	}
+
+	for i := range turns {
+		turns[i].Prompt = maskSecrets(c, "import", turns[i].Prompt)
+		turns[i].Answer = maskSecrets(c, "import", turns[i].Answer)
+	}
+}
+
+// maskHistory returns the prompt with masked secrets of the history messages if the chat policy is not "off",
+// so turns sent as is after the confirmation or restored from other sources are not repeated in later requests.
+func (b *Bot) maskHistory(c telebot.Context, prompt *config.Prompt) *config.Prompt {
+	if len(prompt.History) == 0 || b.cfg.Secrets.ChatPolicy(c.Chat().ID) == config.SecretsOff {
+		return prompt
+	}
+
+	masked := *prompt
+	masked.History = make([]ygpt.Message, len(prompt.History))
+
+	for i, message := range prompt.History {
+		message.Text = maskSecrets(c, "history", message.Text)
+		masked.History[i] = message
+	}
+
+	return &masked
+}
+
+// maskSecrets returns the text with masked secrets, found types are logged.
+func maskSecrets(c telebot.Context, name, text string) string {
+	masked, findings := secrets.Mask(text)
+	if len(findings) > 0 {
+		logger(c).Warn("secrets are masked", "in", name, "types", secrets.Types(findings))
+	}
+
+	return masked
+}
+
+// askConfirmation saves the prompt and replies with the found secrets types and decision buttons.
+func (b *Bot) askConfirmation(c telebot.Context, key msgKey, p prompt, findings []secrets.Finding) error {
+	types := secrets.Types(findings)
+	logger(c).Warn("secrets confirmation", "types", types)
+
+	b.confirmations.add(key, confirmation{c: c, p: p, userID: c.Sender().ID})
+	return b.sendResult(c, key, tr(c, i18n.SecretsFound, strings.Join(types, ", ")), secretsMarkup(c, key))
+}
+
+// secretsMarkup returns an inline keyboard with the localized decision buttons for the prompt message.
+func secretsMarkup(c telebot.Context, key msgKey) *telebot.ReplyMarkup {
+	var (
+		data    = strconv.Itoa(key.messageID)
+		buttons = []telebot.InlineButton{btnSecretsSend, btnSecretsMask, btnSecretsCancel}
+		keys    = []i18n.Key{i18n.SecretsSendButton, i18n.SecretsMaskButton, i18n.SecretsCancelButton}
+	)
+
+	for i := range buttons {
+		buttons[i].Text, buttons[i].Data = tr(c, keys[i]), data
+	}
+
+	return &telebot.ReplyMarkup{InlineKeyboard: [][]telebot.InlineButton{buttons}}
+}
+
+// secretsHandler returns a handler of the decision button about the prompt with secrets,
+// the prompt is handled with the context of its original message.
+func (b *Bot) secretsHandler(btn telebot.InlineButton) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		messageID, err := strconv.Atoi(c.Data())
+		if err != nil {
+			return fmt.Errorf("invalid secrets button data %q: %w", c.Data(), err)
+		}
+
+		key := msgKey{chatID: c.Chat().ID, messageID: messageID}
+
+		item, ok := b.confirmations.take(key, c.Sender().ID)
+		if !ok {
+			return c.Respond(&telebot.CallbackResponse{Text: tr(c, i18n.SecretsExpired)})
+		}
+
+		if err = c.Respond(); err != nil {
+			logger(c).Warn("failed to respond", "error", err)
+		}
+
+		item.c.Set(traceKey, traceContext(c))
+		logger(item.c).Info("secrets decision", "button", btn.Unique)
+
+		switch btn.Unique {
+		case btnSecretsSend.Unique:
+		case btnSecretsMask.Unique:
+			item.p.content = maskSecrets(item.c, "prompt", item.p.content)
+		default:
+			return b.sendResult(item.c, key, tr(item.c, i18n.Cancelled), nil)
+		}
+
+		item.p.confirmed = true
+		return b.answer(item.c, item.p)
+	}
+}
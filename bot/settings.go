@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"sync"
+
+	"github.com/z0rr0/tgtpgybot/document"
+	"github.com/z0rr0/tgtpgybot/i18n"
+)
+
+// chatSettings is a per-chat bot settings.
+type chatSettings struct {
+	voice    bool               // send answers as voice notes too
+	document *document.Document // uploaded document to use as a context
+	lang     i18n.Lang          // language set by /lang command, it is detected by the user if empty
+}
+
+// settings keeps per-chat bot settings in memory.
+type settings struct {
+	sync.Mutex
+	chats map[int64]chatSettings
+}
+
+// newSettings returns a new empty settings storage.
+func newSettings() *settings {
+	return &settings{chats: make(map[int64]chatSettings)}
+}
+
+// get returns the chat settings, default values are used for unknown chats.
+func (s *settings) get(chatID int64) chatSettings {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.chats[chatID]
+}
+
+// update changes the chat settings by the function.
+func (s *settings) update(chatID int64, f func(cs *chatSettings)) {
+	s.Lock()
+	defer s.Unlock()
+
+	cs := s.chats[chatID]
+	f(&cs)
+	s.chats[chatID] = cs
+}
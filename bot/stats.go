@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/z0rr0/tgtpgybot/config"
+)
+
+// dayLayout is a format of the day key to reset daily counters.
+const dayLayout = "2006-01-02"
+
+// stats keeps in-memory usage counters since the bot start.
+type stats struct {
+	sync.Mutex
+	started     time.Time
+	requests    int64 // generation requests
+	errors      int64 // failed generations
+	tokens      int64 // used tokens since the start
+	day         string
+	tokensToday int64
+	now         func() time.Time
+}
+
+// statsSnapshot is a copy of the usage counters.
+type statsSnapshot struct {
+	Uptime      time.Duration
+	Requests    int64
+	Errors      int64
+	Tokens      int64
+	TokensToday int64
+}
+
+// ErrorRate returns a part of failed requests in percent.
+func (s statsSnapshot) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+
+	return float64(s.Errors) * 100 / float64(s.Requests)
+}
+
+// newStats returns new counters started now.
+func newStats() *stats {
+	now := time.Now
+	return &stats{started: now(), day: now().Format(dayLayout), now: now}
+}
+
+// add counts the generation request with used tokens, failed ones are counted as errors.
+func (s *stats) add(tokens int64, failed bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.requests++
+	if failed {
+		s.errors++
+	}
+
+	if day := s.now().Format(dayLayout); day != s.day {
+		s.day, s.tokensToday = day, 0
+	}
+
+	s.tokens += tokens
+	s.tokensToday += tokens
+}
+
+// snapshot returns the current counters.
+func (s *stats) snapshot() statsSnapshot {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now()
+	result := statsSnapshot{
+		Uptime:      now.Sub(s.started),
+		Requests:    s.requests,
+		Errors:      s.errors,
+		Tokens:      s.tokens,
+		TokensToday: s.tokensToday,
+	}
+
+	if now.Format(dayLayout) != s.day {
+		result.TokensToday = 0
+	}
+
+	return result
+}
+
+// countRequest updates usage counters by the generation result,
+// cancelled generations are not errors and cached answers do not use tokens.
+func (b *Bot) countRequest(ctx context.Context, result *config.Answer, err error) {
+	switch {
+	case err != nil:
+		b.stats.add(0, !errors.Is(ctx.Err(), context.Canceled))
+	case result.Cached:
+		b.stats.add(0, false)
+	default:
+		b.stats.add(result.Tokens, false)
+	}
+}
@@ -0,0 +1,35 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	var (
+		now = time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+		s   = &stats{started: now, day: now.Format(dayLayout), now: func() time.Time { return now }}
+	)
+
+	s.add(10, false)
+	s.add(5, true)
+
+	snapshot := s.snapshot()
+	if snapshot.Requests != 2 || snapshot.Errors != 1 || snapshot.Tokens != 15 || snapshot.TokensToday != 15 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+
+	now = now.Add(2 * time.Hour) // next day
+	if snapshot = s.snapshot(); snapshot.TokensToday != 0 || snapshot.Uptime != 2*time.Hour {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+
+	s.add(7, false)
+	if snapshot = s.snapshot(); snapshot.Tokens != 22 || snapshot.TokensToday != 7 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+
+	if rate := (statsSnapshot{}).ErrorRate(); rate != 0 {
+		t.Errorf("unexpected error rate: %v", rate)
+	}
+}
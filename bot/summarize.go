@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/webpage"
+)
+
+// urlRegexp is a regular expression to find URLs in messages.
+var urlRegexp = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// summarizeHandler fetches a web page by URL from the command or the replied message
+// and answers with its summary.
+func (b *Bot) summarizeHandler(c telebot.Context) error {
+	var (
+		message = c.Message()
+		pageURL = urlRegexp.FindString(commandPayload(message))
+	)
+
+	if pageURL == "" && message.ReplyTo != nil {
+		pageURL = urlRegexp.FindString(message.ReplyTo.Text + " " + message.ReplyTo.Caption)
+	}
+
+	if pageURL == "" {
+		return c.Send(tr(c, i18n.SummarizeUsage))
+	}
+
+	var (
+		key  = newMsgKey(message)
+		page *webpage.Page
+	)
+
+	err := b.tracked(c, key, func(ctx context.Context) (err error) {
+		page, err = b.cfg.Chat.Page(ctx, pageURL, &b.cfg.Pages, message.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return b.cancelled(c, key, err)
+		}
+		return c.Send(tr(c, i18n.PageFailed, userError(c, err)))
+	}
+
+	if page.Text == "" {
+		return c.Send(tr(c, i18n.PageEmpty))
+	}
+
+	content := fmt.Sprintf("URL: %s\nTitle: %s\n\n%s", page.URL, page.Title, page.Text)
+	return b.answer(c, prompt{content: content, instruction: tr(c, i18n.PageInstruction)})
+}
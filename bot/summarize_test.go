@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+func TestBotSummarizeHandler(t *testing.T) {
+	pages := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, "<html><title>News</title><body><nav>Menu</nav><p>Go 1.21 is released.</p></body></html>")
+	}))
+	defer pages.Close()
+
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &ygpt.TextGenerationChat{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		if request.InstructionText != i18n.English.T(i18n.PageInstruction) {
+			t.Errorf("unexpected instruction: %q", request.InstructionText)
+		}
+
+		text := request.Messages[0].Text
+		if !strings.Contains(text, "Title: News\n\nGo 1.21 is released.") || strings.Contains(text, "Menu") {
+			t.Errorf("unexpected text: %q", text)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"result":{"message":{"role":"Ассистент","text":"New Go version"},"num_tokens":"20"}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}), func(cfg *config.Config) {
+		cfg.Pages = config.Pages{MaxSize: 1000, MaxChars: 1000, Deny: []string{"example.com"}}
+	})
+
+	newContext := func(id int, text string, replyTo *telebot.Message) *testContext {
+		message := &telebot.Message{ID: id, Chat: &telebot.Chat{ID: 1}, Text: text, ReplyTo: replyTo}
+		return &testContext{update: telebot.Update{Message: message}}
+	}
+
+	if err := b.summarizeHandler(newContext(1, "/summarize", &telebot.Message{Text: "see " + pages.URL + "/page"})); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := tg.LastText(); text != "New Go version" {
+		t.Errorf("unexpected answer: %q", text)
+	}
+
+	// denied domain and no URL
+	calls := len(tg.Calls(""))
+	for i, text := range []string{"/summarize https://example.com/page", "/summarize"} {
+		if err := b.summarizeHandler(newContext(i+2, text, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := len(tg.Calls("")); n != calls {
+		t.Errorf("unexpected calls: %v", tg.Calls("")[calls:])
+	}
+}
@@ -0,0 +1,193 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+)
+
+// maxUserTemplates is a maximum number of templates per user.
+const maxUserTemplates = 50
+
+// templates keeps prompt templates from the config and users' ones in memory.
+type templates struct {
+	sync.Mutex
+	common map[string]string
+	users  map[int64]map[string]string
+}
+
+// newTemplates returns a new templates storage with common templates.
+func newTemplates(common map[string]string) *templates {
+	return &templates{common: common, users: make(map[int64]map[string]string)}
+}
+
+// get returns a template text by its name, user's templates have priority.
+func (t *templates) get(userID int64, name string) (string, bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	if text, ok := t.users[userID][name]; ok {
+		return text, true
+	}
+
+	text, ok := t.common[name]
+	return text, ok
+}
+
+// add adds or replaces user's template.
+func (t *templates) add(userID int64, name, text string) error {
+	if _, err := config.ParseTemplate(name, text); err != nil {
+		return err
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	userTemplates, ok := t.users[userID]
+	if !ok {
+		userTemplates = make(map[string]string)
+		t.users[userID] = userTemplates
+	}
+
+	if _, ok = userTemplates[name]; !ok && len(userTemplates) >= maxUserTemplates {
+		return fmt.Errorf("too many templates, maximum is %d", maxUserTemplates)
+	}
+
+	userTemplates[name] = text
+	return nil
+}
+
+// del deletes user's template, it returns false if the template is not found.
+func (t *templates) del(userID int64, name string) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.users[userID][name]; !ok {
+		return false
+	}
+
+	delete(t.users[userID], name)
+	return true
+}
+
+// list returns a description of available templates for the user.
+func (t *templates) list(userID int64, lang i18n.Lang) string {
+	var b strings.Builder
+
+	t.Lock()
+	defer t.Unlock()
+
+	write := func(title string, items map[string]string) {
+		names := make([]string, 0, len(items))
+		for name := range items {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		b.WriteString(title + ":\n")
+		for _, name := range names {
+			b.WriteString(fmt.Sprintf("- %s: %s\n", name, items[name]))
+		}
+	}
+
+	if len(t.common) > 0 {
+		write(lang.T(i18n.TemplatesCommon), t.common)
+	}
+
+	if userTemplates := t.users[userID]; len(userTemplates) > 0 {
+		write(lang.T(i18n.TemplatesUser), userTemplates)
+	}
+
+	if b.Len() == 0 {
+		return lang.T(i18n.TemplatesNone)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// splitFirst splits the text into the first word and the rest.
+func splitFirst(text string) (string, string) {
+	text = strings.TrimSpace(text)
+
+	if i := strings.IndexAny(text, " \t\n"); i >= 0 {
+		return text[:i], strings.TrimSpace(text[i:])
+	}
+
+	return text, ""
+}
+
+// templateHandler manages user's prompt templates.
+func (b *Bot) templateHandler(c telebot.Context) error {
+	var (
+		userID           = c.Sender().ID
+		action, args     = splitFirst(commandPayload(c.Message()))
+		name, tmplString = splitFirst(args)
+	)
+
+	switch action {
+	case "list", "":
+		return c.Send(b.templates.list(userID, lang(c)))
+	case "add":
+		if name == "" || tmplString == "" {
+			return c.Send(tr(c, i18n.TemplateUsage))
+		}
+
+		if err := b.templates.add(userID, name, tmplString); err != nil {
+			return c.Send(tr(c, i18n.Failed, userError(c, err)))
+		}
+
+		return c.Send(tr(c, i18n.TemplateSaved, name, name))
+	case "del":
+		if !b.templates.del(userID, name) {
+			return c.Send(tr(c, i18n.TemplateNotOwned, name))
+		}
+
+		return c.Send(tr(c, i18n.TemplateDeleted, name))
+	default:
+		return c.Send(tr(c, i18n.TemplateUsage))
+	}
+}
+
+// templatePromptHandler renders the template for the command text or the replied message
+// and handles the result as a prompt.
+func (b *Bot) templatePromptHandler(c telebot.Context) error {
+	var (
+		message     = c.Message()
+		name, input = splitFirst(commandPayload(message))
+	)
+
+	if name == "" {
+		return c.Send(tr(c, i18n.TemplatePromptUsage))
+	}
+
+	text, ok := b.templates.get(c.Sender().ID, name)
+	if !ok {
+		return c.Send(tr(c, i18n.TemplateNotFound, name))
+	}
+
+	if input == "" && message.ReplyTo != nil {
+		input = strings.TrimSpace(message.ReplyTo.Text + message.ReplyTo.Caption)
+	}
+
+	if input == "" {
+		return c.Send(tr(c, i18n.TemplateEmptyInput))
+	}
+
+	tmpl, err := config.ParseTemplate(name, text)
+	if err != nil {
+		return c.Send(tr(c, i18n.Failed, userError(c, err)))
+	}
+
+	content, err := config.RenderTemplate(tmpl, input)
+	if err != nil {
+		return c.Send(tr(c, i18n.Failed, userError(c, err)))
+	}
+
+	return b.answer(c, prompt{content: content})
+}
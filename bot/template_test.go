@@ -0,0 +1,142 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+func TestTemplates(t *testing.T) {
+	tmpl := newTemplates(map[string]string{"review": "Review:\n{{.Input}}"})
+
+	if err := tmpl.add(1, "Bad name", "text"); err == nil {
+		t.Error("expected error")
+	}
+
+	if err := tmpl.add(1, "review", "My review:\n{{.Input}}"); err != nil {
+		t.Fatal(err)
+	}
+
+	if text, ok := tmpl.get(1, "review"); !ok || text != "My review:\n{{.Input}}" {
+		t.Errorf("unexpected user template: %q", text)
+	}
+
+	if text, ok := tmpl.get(2, "review"); !ok || text != "Review:\n{{.Input}}" {
+		t.Errorf("unexpected common template: %q", text)
+	}
+
+	expected := "Common templates:\n- review: Review:\n{{.Input}}\nYour templates:\n- review: My review:\n{{.Input}}"
+	if s := tmpl.list(1, i18n.English); s != expected {
+		t.Errorf("unexpected list: %q", s)
+	}
+
+	if !tmpl.del(1, "review") {
+		t.Error("template is not deleted")
+	}
+
+	if tmpl.del(1, "review") {
+		t.Error("common template is deleted")
+	}
+
+	for i := 0; i < maxUserTemplates; i++ {
+		if err := tmpl.add(3, fmt.Sprintf("t%d", i), "text"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tmpl.add(3, "extra", "text"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestBotTemplatePromptHandler(t *testing.T) {
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &ygpt.TextGenerationChat{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		expected := "Review this Go code for bugs:\nfunc main() {\n\tpanic(1)\n}"
+		if text := request.Messages[0].Text; text != expected {
+			t.Errorf("unexpected text: %q", text)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"result":{"message":{"role":"Ассистент","text":"It panics"},"num_tokens":"20"}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}), func(cfg *config.Config) {
+		cfg.Templates = map[string]string{"review": "Review this Go code for bugs:\n{{.Input}}"}
+	})
+
+	newContext := func(id int, text string, replyTo *telebot.Message) *testContext {
+		message := &telebot.Message{ID: id, Chat: &telebot.Chat{ID: 1}, Text: text, ReplyTo: replyTo}
+		return &testContext{update: telebot.Update{Message: message}}
+	}
+
+	code := "func main() {\n\tpanic(1)\n}"
+	if err := b.templatePromptHandler(newContext(1, "/t review "+code, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := tg.LastText(); text != "It panics" {
+		t.Errorf("unexpected answer: %q", text)
+	}
+
+	if err := b.templatePromptHandler(newContext(2, "/t review", &telebot.Message{Text: code})); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, text := len(tg.Calls("sendMessage")), tg.LastText(); n != 2 || text != "It panics" {
+		t.Errorf("unexpected answers: %d, %q", n, text)
+	}
+
+	// unknown template and user's templates management
+	commands := []string{"/template unknown", "/template add short Be short:\n{{.Input}}", "/template list", "/template del short"}
+	for i, command := range commands {
+		if err := b.templateHandler(newContext(i+3, command, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := b.templatePromptHandler(newContext(10, "/t unknown text", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := b.templates.get(1, "short"); ok {
+		t.Error("template is not deleted")
+	}
+
+	if err := b.templateHandler(newContext(11, "/template add short Be short:\n{{.Input}}", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if text, ok := b.templates.get(1, "short"); !ok || !strings.HasPrefix(text, "Be short") {
+		t.Errorf("unexpected template: %q", text)
+	}
+}
+
+func TestBotTemplateHandlerFailed(t *testing.T) {
+	b := newAdminBot(t)
+
+	c := commandContext("/template add review {{.Input")
+	if err := b.templateHandler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// the parsing error is logged, only its reference is sent
+	text, _ := c.lastSent().(string)
+	if !strings.HasPrefix(text, i18n.English.T(i18n.Failed, "")) || strings.Contains(text, "unclosed action") {
+		t.Errorf("unexpected reply: %q", text)
+	}
+}
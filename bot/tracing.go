@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/tracing"
+)
+
+// traceKey is a handler context key of the update span context.
+const traceKey = "trace"
+
+// tracingMiddleware starts a span for every Telegram update, handler errors are recorded to it.
+func tracingMiddleware() telebot.MiddlewareFunc {
+	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
+		return func(c telebot.Context) error {
+			ctx, span := tracing.Tracer().Start(
+				context.Background(), "telegram.update",
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(updateAttributes(c)...),
+			)
+			defer span.End()
+
+			c.Set(traceKey, ctx)
+
+			err := next(c)
+			if err != nil {
+				tracing.Fail(span, err)
+			}
+
+			return err
+		}
+	}
+}
+
+// updateAttributes returns span attributes of the update's message, user and chat IDs.
+func updateAttributes(c telebot.Context) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.Int("telegram.update_id", c.Update().ID)}
+
+	if m := c.Message(); m != nil {
+		attrs = append(attrs, attribute.Int("telegram.message_id", m.ID))
+	}
+
+	if user := c.Sender(); user != nil {
+		attrs = append(attrs, attribute.Int64("telegram.user_id", user.ID))
+	}
+
+	if chat := c.Chat(); chat != nil {
+		attrs = append(attrs, attribute.Int64("telegram.chat_id", chat.ID))
+	}
+
+	return attrs
+}
+
+// traceContext returns a context with the update span of the handler context.
+func traceContext(c telebot.Context) context.Context {
+	if ctx, ok := c.Get(traceKey).(context.Context); ok {
+		return ctx
+	}
+
+	return context.Background()
+}
+
+// withUpdateSpan returns a copy of the context with the update span as a parent of new spans,
+// it keeps the context cancellation, so generations are not bound to the handler lifetime.
+func withUpdateSpan(ctx context.Context, c telebot.Context) context.Context {
+	return trace.ContextWithSpan(ctx, trace.SpanFromContext(traceContext(c)))
+}
+
+// historyTurns returns previous turns of the conversation.
+func (b *Bot) historyTurns(ctx context.Context, key msgKey) []turn {
+	_, span := tracing.Tracer().Start(ctx, "history.lookup")
+	defer span.End()
+
+	turns := b.history.before(key.chatID, key.messageID)
+	span.SetAttributes(attribute.Int("history.turns", len(turns)))
+
+	return turns
+}
+
+// cacheLookup returns a cached answer by the key.
+func (b *Bot) cacheLookup(ctx context.Context, key string) (config.Answer, bool) {
+	_, span := tracing.Tracer().Start(ctx, "cache.lookup")
+	defer span.End()
+
+	answer, ok := b.cache.Get(key)
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+
+	return answer, ok
+}
+
+// tracedSend wraps the Telegram send function with a span of the API method.
+func tracedSend(c telebot.Context, method string, send sendFunc) sendFunc {
+	return func(opts *telebot.SendOptions) (*telebot.Message, error) {
+		_, span := tracing.Tracer().Start(
+			traceContext(c), "telegram."+method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("telegram.parse_mode", string(opts.ParseMode))),
+		)
+		defer span.End()
+
+		m, err := send(opts)
+		if err != nil {
+			tracing.Fail(span, err)
+		}
+
+		return m, err
+	}
+}
@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/tracing"
+)
+
+// newTestExporter sets a global tracer provider with in-memory exporter until the test end.
+func newTestExporter(t *testing.T) *tracetest.InMemoryExporter {
+	var (
+		exporter = tracetest.NewInMemoryExporter()
+		previous = otel.GetTracerProvider()
+		provider = tracing.NewProvider("test", sdktrace.WithSyncer(exporter))
+	)
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		if err := provider.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return exporter
+}
+
+// spanAttribute returns a value of the span attribute.
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	b, _ := newFixture(t, answerHandler(t, "Меня зовут Алиса"), func(cfg *config.Config) {
+		cfg.Chat.Client = &http.Client{Transport: tracing.Transport(cfg.Chat.Client.Transport)}
+	})
+
+	handler := tracingMiddleware()(b.rootHandler)
+	if err := handler(&testContext{}); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+
+	update, ok := spans["telegram.update"]
+	if !ok {
+		t.Fatalf("no update span: %v", spans)
+	}
+
+	if v, _ := spanAttribute(update, "telegram.message_id"); v.AsInt64() != 2 {
+		t.Errorf("unexpected message ID: %v", v.Emit())
+	}
+
+	if v, _ := spanAttribute(update, tracing.AttrTokens); v.AsInt64() != 20 {
+		t.Errorf("unexpected update tokens: %v", v.Emit())
+	}
+
+	children := []string{"telegram.sendMessage", "history.lookup", "ygpt.generation", "telegram.editMessageText"}
+	for _, name := range children {
+		span, found := spans[name]
+		if !found {
+			t.Errorf("no span %q", name)
+			continue
+		}
+
+		if span.Parent.SpanID() != update.SpanContext.SpanID() {
+			t.Errorf("span %q is not a child of the update", name)
+		}
+	}
+
+	generation := spans["ygpt.generation"]
+	if v, _ := spanAttribute(generation, tracing.AttrModel); v.AsString() != "general" {
+		t.Errorf("unexpected model: %v", v.Emit())
+	}
+
+	if v, _ := spanAttribute(generation, tracing.AttrTokens); v.AsInt64() != 20 {
+		t.Errorf("unexpected generation tokens: %v", v.Emit())
+	}
+
+	var httpSpans int
+	for _, span := range exporter.GetSpans() {
+		if span.Parent.SpanID() == generation.SpanContext.SpanID() {
+			httpSpans++
+		}
+	}
+
+	if httpSpans != 1 {
+		t.Errorf("unexpected number of HTTP spans: %d", httpSpans)
+	}
+}
+
+func TestTracingMiddlewareError(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	handler := tracingMiddleware()(func(c telebot.Context) error {
+		if _, ok := c.Get(traceKey).(context.Context); !ok {
+			t.Error("trace context is not set")
+		}
+		return errors.New("test error")
+	})
+
+	if err := handler(&testContext{}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	spans := exporter.GetSpans()
+	if n := len(spans); n != 1 {
+		t.Fatalf("unexpected number of spans: %d", n)
+	}
+
+	if status := spans[0].Status; status.Code != codes.Error {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"gopkg.in/telebot.v3"
+)
+
+// maxAnswers is a maximum number of remembered prompt-answer pairs.
+const maxAnswers = 1024
+
+var (
+	// errReplaced is a cancellation cause when a newer version of the prompt message is handled.
+	errReplaced = errors.New("replaced by edited message")
+
+	// errCancelled is a cancellation cause when a user cancels the generation.
+	errCancelled = errors.New("cancelled by user")
+
+	// errStopped is a cancellation cause when the bot is stopping.
+	errStopped = errors.New("bot is stopping")
+)
+
+// msgKey identifies a message in a chat.
+type msgKey struct {
+	chatID    int64
+	messageID int
+}
+
+// newMsgKey returns a key of the message.
+func newMsgKey(m *telebot.Message) msgKey {
+	var chatID int64
+
+	if m.Chat != nil {
+		chatID = m.Chat.ID
+	}
+
+	return msgKey{chatID: chatID, messageID: m.ID}
+}
+
+// generation is an in-flight answer generation.
+type generation struct {
+	id     uint64
+	cancel context.CancelCauseFunc
+}
+
+// tracker keeps in-flight generations and bot's answers for prompt messages.
+type tracker struct {
+	sync.Mutex
+	wg       sync.WaitGroup
+	counter  uint64
+	stopped  error // cancellation cause of the shutdown, new generations are not started if it is set
+	inflight map[msgKey]generation
+	answers  map[msgKey]telebot.StoredMessage
+	order    []msgKey
+}
+
+// newTracker returns a new empty tracker.
+func newTracker() *tracker {
+	return &tracker{
+		inflight: make(map[msgKey]generation),
+		answers:  make(map[msgKey]telebot.StoredMessage),
+	}
+}
+
+// start returns a new generation context for the prompt message.
+// An in-flight generation for the previous version of the same message is cancelled.
+// The returned function must be called when the generation is finished.
+// The context is already cancelled if the tracker is stopped.
+func (t *tracker) start(key msgKey, timeout time.Duration) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
+
+	t.Lock()
+	defer t.Unlock()
+
+	if t.stopped != nil {
+		cancel(t.stopped)
+		return ctx, cancelTimeout
+	}
+
+	if g, ok := t.inflight[key]; ok {
+		g.cancel(errReplaced)
+	}
+
+	t.counter++
+	id := t.counter
+	t.inflight[key] = generation{id: id, cancel: cancel}
+	t.wg.Add(1)
+
+	return ctx, func() {
+		cancelTimeout()
+		cancel(nil)
+
+		t.Lock()
+		defer t.Unlock()
+
+		// the generation can be already replaced by a newer one
+		if g, ok := t.inflight[key]; ok && g.id == id {
+			delete(t.inflight, key)
+		}
+		t.wg.Done()
+	}
+}
+
+// cancel cancels an in-flight generation for the prompt message.
+func (t *tracker) cancel(key msgKey, cause error) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	g, ok := t.inflight[key]
+	if ok {
+		g.cancel(cause)
+	}
+
+	return ok
+}
+
+// cancelChat cancels all in-flight generations in the chat and returns their number.
+func (t *tracker) cancelChat(chatID int64, cause error) int {
+	var n int
+
+	t.Lock()
+	defer t.Unlock()
+
+	for key, g := range t.inflight {
+		if key.chatID == chatID {
+			g.cancel(cause)
+			n++
+		}
+	}
+
+	return n
+}
+
+// stop cancels all in-flight generations and refuses to start new ones,
+// so wait can be called after it.
+func (t *tracker) stop(cause error) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.stopped = cause
+	for _, g := range t.inflight {
+		g.cancel(cause)
+	}
+}
+
+// wait waits for all started generations to finish, the tracker must be stopped.
+func (t *tracker) wait() {
+	t.wg.Wait()
+}
+
+// answer returns the bot's answer for the prompt message.
+func (t *tracker) answer(key msgKey) (telebot.StoredMessage, bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	m, ok := t.answers[key]
+	return m, ok
+}
+
+// setAnswer remembers the bot's answer for the prompt message.
+// The oldest pairs are forgotten if there are more than maxAnswers of them.
+func (t *tracker) setAnswer(key msgKey, m *telebot.Message) {
+	stored := telebot.StoredMessage{MessageID: strconv.Itoa(m.ID), ChatID: key.chatID}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if _, ok := t.answers[key]; !ok {
+		t.order = append(t.order, key)
+	}
+	t.answers[key] = stored
+
+	for len(t.order) > maxAnswers {
+		delete(t.answers, t.order[0])
+		t.order = t.order[1:]
+	}
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/telebot.v3"
+)
+
+func TestTrackerStart(t *testing.T) {
+	tr := newTracker()
+	key := msgKey{chatID: 1, messageID: 2}
+
+	ctx1, finish1 := tr.start(key, time.Minute)
+	ctx2, finish2 := tr.start(key, time.Minute)
+
+	if cause := context.Cause(ctx1); !errors.Is(cause, errReplaced) {
+		t.Errorf("unexpected cancellation cause: %v", cause)
+	}
+
+	// the first generation must not remove the second one
+	finish1()
+
+	if _, ok := tr.inflight[key]; !ok {
+		t.Error("in-flight generation is removed")
+	}
+
+	if err := ctx2.Err(); err != nil {
+		t.Errorf("unexpected context error: %v", err)
+	}
+
+	finish2()
+
+	if n := len(tr.inflight); n != 0 {
+		t.Errorf("unexpected number of in-flight generations: %d", n)
+	}
+}
+
+func TestTrackerAnswer(t *testing.T) {
+	tr := newTracker()
+
+	for i := 0; i < maxAnswers+10; i++ {
+		tr.setAnswer(msgKey{chatID: 1, messageID: i}, &telebot.Message{ID: i + 1})
+	}
+
+	if n := len(tr.answers); n != maxAnswers {
+		t.Errorf("unexpected number of answers: %d", n)
+	}
+
+	if _, ok := tr.answer(msgKey{chatID: 1, messageID: 0}); ok {
+		t.Error("the oldest answer is not removed")
+	}
+
+	m, ok := tr.answer(msgKey{chatID: 1, messageID: maxAnswers})
+	if !ok {
+		t.Fatal("answer is not found")
+	}
+
+	if m.MessageID != "1025" || m.ChatID != 1 {
+		t.Errorf("unexpected answer: %#v", m)
+	}
+}
+
+func TestTrackerCancelChat(t *testing.T) {
+	tr := newTracker()
+
+	ctx1, finish1 := tr.start(msgKey{chatID: 1, messageID: 1}, time.Minute)
+	ctx2, finish2 := tr.start(msgKey{chatID: 1, messageID: 2}, time.Minute)
+	ctx3, finish3 := tr.start(msgKey{chatID: 2, messageID: 3}, time.Minute)
+
+	if n := tr.cancelChat(1, errCancelled); n != 2 {
+		t.Errorf("unexpected number of cancelled generations: %d", n)
+	}
+
+	for _, ctx := range []context.Context{ctx1, ctx2} {
+		if cause := context.Cause(ctx); !errors.Is(cause, errCancelled) {
+			t.Errorf("unexpected cancellation cause: %v", cause)
+		}
+	}
+
+	if err := ctx3.Err(); err != nil {
+		t.Errorf("unexpected context error: %v", err)
+	}
+
+	tr.stop(errStopped)
+
+	if cause := context.Cause(ctx3); !errors.Is(cause, errStopped) {
+		t.Errorf("unexpected cancellation cause: %v", cause)
+	}
+
+	// new generations are not started after the stop
+	ctx4, finish4 := tr.start(msgKey{chatID: 2, messageID: 4}, time.Minute)
+	if cause := context.Cause(ctx4); !errors.Is(cause, errStopped) {
+		t.Errorf("unexpected cancellation cause: %v", cause)
+	}
+	finish4()
+
+	finish1()
+	finish2()
+	finish3()
+	tr.wait()
+
+	if tr.cancel(msgKey{chatID: 2, messageID: 3}, errCancelled) {
+		t.Error("finished generation is cancelled")
+	}
+}
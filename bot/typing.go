@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/config"
+)
+
+// typingInterval is a period to refresh the typing chat action,
+// Telegram clients show it for about 5 seconds.
+const typingInterval = 4 * time.Second
+
+// startTyping sends the typing chat action immediately and refreshes it
+// until the context is done or the returned stop function is called.
+func startTyping(ctx context.Context, c telebot.Context) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(typingInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := c.Notify(telebot.Typing); err != nil {
+				slog.Debug("failed to send typing action", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// generate returns a generated answer for the prompt, the typing chat action is shown while waiting.
+// Deterministic answers are cached if it is enabled, the prompt is moderated and its history secrets are masked
+// before the cache lookup.
+func (b *Bot) generate(ctx context.Context, c telebot.Context, prompt *config.Prompt, messageID int) (*config.Answer, error) {
+	prompt, err := b.cfg.Chat.ModeratePrompt(prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt = b.maskHistory(c, prompt)
+
+	key, cacheable := b.cacheKey(prompt)
+	if cacheable {
+		if answer, ok := b.cacheLookup(ctx, key); ok {
+			slog.Info("cache hit", "id", messageID, "stats", b.cache.Stats())
+			answer.Cached = true
+			return &answer, nil
+		}
+		slog.Debug("cache miss", "id", messageID, "stats", b.cache.Stats())
+	}
+
+	stop := startTyping(ctx, c)
+	defer stop()
+
+	answer, err := b.cfg.Chat.Generation(ctx, prompt, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		b.cache.Set(key, *answer)
+	}
+
+	return answer, nil
+}
@@ -0,0 +1,27 @@
+package bot
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStartTyping(t *testing.T) {
+	c := &testContext{}
+
+	stop := startTyping(context.Background(), c)
+	stop()
+
+	if n := c.notified.Load(); n != 1 {
+		t.Errorf("unexpected number of typing actions: %d", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stop = startTyping(ctx, c)
+	stop()
+
+	if n := c.notified.Load(); n != 2 {
+		t.Errorf("unexpected number of typing actions: %d", n)
+	}
+}
@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/speechkit"
+)
+
+// voiceHandler recognizes a voice message and handles its text as a prompt.
+func (b *Bot) voiceHandler(c telebot.Context) error {
+	var (
+		key   = newMsgKey(c.Message())
+		voice = c.Message().Voice
+		text  string
+	)
+
+	if d := time.Duration(voice.Duration) * time.Second; d > speechkit.MaxAudioDuration {
+		return c.Send(tr(c, i18n.VoiceTooLong, speechkit.MaxAudioDuration))
+	}
+
+	if voice.FileSize > speechkit.MaxAudioSize {
+		return c.Send(tr(c, i18n.VoiceTooLarge))
+	}
+
+	err := b.tracked(c, key, func(ctx context.Context) (err error) {
+		text, err = b.recognize(ctx, key.messageID, &voice.File)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return b.cancelled(c, key, err)
+		}
+		return c.Send(tr(c, i18n.VoiceFailed, userError(c, err)))
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return c.Send(tr(c, i18n.VoiceNotRecognized))
+	}
+
+	if err = c.Send(tr(c, i18n.VoiceRecognized, text)); err != nil {
+		return err
+	}
+
+	return b.answer(c, prompt{content: text})
+}
+
+// recognize downloads the voice file from Telegram and returns its recognized text.
+func (b *Bot) recognize(ctx context.Context, messageID int, file *telebot.File) (string, error) {
+	reader, err := b.bot.File(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to download file: %w", err)
+	}
+
+	defer func() {
+		if e := reader.Close(); e != nil {
+			slog.Error("failed to close file", "id", messageID, "error", e)
+		}
+	}()
+
+	return b.cfg.Chat.Recognition(ctx, reader, messageID)
+}
+
+// say sends the text as synthesized voice notes,
+// a long text is split into several ones which fit the synthesis limit.
+func (b *Bot) say(ctx context.Context, c telebot.Context, messageID int, text string) error {
+	for _, part := range speechkit.SplitText(text) {
+		data, err := b.cfg.Chat.Synthesis(ctx, part, messageID)
+		if err != nil {
+			return c.Send(tr(c, i18n.SynthesisFailed, userError(c, err)))
+		}
+
+		voice := &telebot.Voice{File: telebot.FromReader(bytes.NewReader(data)), MIME: "audio/ogg"}
+		if err = c.Send(voice); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sayHandler generates an answer for the command payload or the replied message
+// and sends it as a voice note in addition to the text.
+func (b *Bot) sayHandler(c telebot.Context) error {
+	var (
+		message = c.Message()
+		content = commandPayload(message)
+	)
+
+	if content == "" && message.ReplyTo != nil {
+		content = strings.TrimSpace(message.ReplyTo.Text)
+	}
+
+	if content == "" {
+		return c.Send(tr(c, i18n.SayUsage))
+	}
+
+	return b.answer(c, prompt{content: content, speak: true})
+}
+
+// voiceModeHandler switches per-chat voice replies mode.
+func (b *Bot) voiceModeHandler(c telebot.Context) error {
+	var chatID = c.Chat().ID
+
+	switch arg := strings.TrimSpace(c.Message().Payload); arg {
+	case "on", "off":
+		b.settings.update(chatID, func(cs *chatSettings) { cs.voice = arg == "on" })
+	case "":
+	default:
+		return c.Send(tr(c, i18n.VoiceUsage))
+	}
+
+	if b.settings.get(chatID).voice {
+		return c.Send(tr(c, i18n.VoiceOn))
+	}
+
+	return c.Send(tr(c, i18n.VoiceOff))
+}
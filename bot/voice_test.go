@@ -0,0 +1,229 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+
+	"gopkg.in/telebot.v3"
+
+	"github.com/z0rr0/tgtpgybot/i18n"
+	"github.com/z0rr0/tgtpgybot/speechkit"
+)
+
+func TestBotVoiceHandler(t *testing.T) {
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var response string
+		switch r.URL.Path {
+		case "/stt":
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if b := string(body); b != "OggS" {
+				t.Errorf("unexpected audio: %q", b)
+			}
+
+			response = `{"result":"Кто ты?"}`
+		default:
+			response = `{"result":{"message":{"role":"Ассистент","text":"Меня зовут Алиса"},"num_tokens":"20"}}`
+		}
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	tg.AddFile("test", []byte("OggS"))
+
+	voice := &telebot.Voice{File: telebot.File{FileID: "test", FileSize: 4}, Duration: 2}
+	message := &telebot.Message{ID: 2, Chat: &telebot.Chat{ID: 1}, Voice: voice}
+
+	if err := b.voiceHandler(&testContext{update: telebot.Update{Message: message}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if text := tg.LastText(); text != "Меня зовут Алиса" {
+		t.Errorf("unexpected answer: %q", text)
+	}
+
+	// too long voice message is not recognized
+	voice.Duration = 60
+	calls := len(tg.Calls(""))
+
+	if err := b.voiceHandler(&testContext{update: telebot.Update{Message: message}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(tg.Calls("")); n != calls {
+		t.Errorf("unexpected calls: %v", tg.Calls("")[calls:])
+	}
+}
+
+func TestBotSayHandler(t *testing.T) {
+	var synthesized atomic.Int32
+
+	b, _ := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tts" {
+			synthesized.Add(1)
+
+			if text := r.FormValue("text"); text != "Меня зовут Алиса" {
+				t.Errorf("unexpected text: %q", text)
+			}
+
+			if _, err := fmt.Fprint(w, "OggS"); err != nil {
+				t.Error(err)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"result":{"message":{"role":"Ассистент","text":"Меня зовут Алиса"},"num_tokens":"20"}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+
+	newContext := func(id int, text, payload string) *testContext {
+		message := &telebot.Message{ID: id, Chat: &telebot.Chat{ID: 1}, Text: text, Payload: payload}
+		return &testContext{update: telebot.Update{Message: message}}
+	}
+
+	// without payload
+	if err := b.sayHandler(newContext(1, "/say", "")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.sayHandler(newContext(2, "/say Кто ты?", "Кто ты?")); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := synthesized.Load(); n != 1 {
+		t.Errorf("unexpected number of synthesis requests: %d", n)
+	}
+
+	// voice mode
+	if err := b.rootHandler(newContext(3, "Кто ты?", "")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.voiceModeHandler(newContext(4, "/voice on", "on")); err != nil {
+		t.Fatal(err)
+	}
+
+	if !b.settings.get(1).voice {
+		t.Error("voice mode is not enabled")
+	}
+
+	if err := b.rootHandler(newContext(5, "Кто ты?", "")); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := synthesized.Load(); n != 2 {
+		t.Errorf("unexpected number of synthesis requests: %d", n)
+	}
+
+	if err := b.voiceModeHandler(newContext(6, "/voice bad", "bad")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.voiceModeHandler(newContext(7, "/voice off", "off")); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.settings.get(1).voice {
+		t.Error("voice mode is not disabled")
+	}
+}
+
+func TestBotVoiceHandlerCancel(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	b, tg := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer close(release)
+
+	tg.AddFile("test", []byte("OggS"))
+
+	voice := &telebot.Voice{File: telebot.File{FileID: "test", FileSize: 4}, Duration: 2}
+	c := &testContext{update: telebot.Update{Message: &telebot.Message{ID: 2, Chat: &telebot.Chat{ID: 1}, Voice: voice}}}
+
+	done := make(chan error)
+	go func() {
+		done <- b.voiceHandler(c)
+	}()
+
+	<-started
+	if n := b.tracker.cancelChat(1, errCancelled); n != 1 {
+		t.Errorf("unexpected number of cancelled recognitions: %d", n)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if text := tg.LastText(); text != i18n.English.T(i18n.Cancelled) {
+		t.Errorf("unexpected answer: %q", text)
+	}
+}
+
+func TestBotSayLongAnswer(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		lengths []int
+	)
+
+	answer := strings.Repeat(strings.Repeat("a", 99)+". ", 60)[:6000]
+	b, _ := newFixture(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tts" {
+			mu.Lock()
+			lengths = append(lengths, utf8.RuneCountInString(r.FormValue("text")))
+			mu.Unlock()
+
+			if _, err := fmt.Fprint(w, "OggS"); err != nil {
+				t.Error(err)
+			}
+			return
+		}
+
+		answerHandler(t, answer)(w, r)
+	}))
+
+	message := &telebot.Message{ID: 1, Chat: &telebot.Chat{ID: 1}, Text: "/say Расскажи", Payload: "Расскажи"}
+	c := &testContext{update: telebot.Update{Message: message}}
+
+	if err := b.sayHandler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(lengths) != 2 || lengths[0] > speechkit.MaxTextLength || lengths[1] > speechkit.MaxTextLength {
+		t.Errorf("unexpected synthesized texts lengths: %v", lengths)
+	}
+
+	var voices int
+	for _, what := range c.sent {
+		if _, ok := what.(*telebot.Voice); ok {
+			voices++
+		}
+	}
+
+	if voices != 2 {
+		t.Errorf("unexpected voice notes: %d", voices)
+	}
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Stats is a cache usage statistics.
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+// entry is a cached value.
+type entry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// Cache is a LRU cache with expiration of values.
+type Cache[V any] struct {
+	sync.Mutex
+	size   int
+	ttl    time.Duration
+	items  map[string]*list.Element
+	order  *list.List // the front is the most recently used entry
+	hits   uint64
+	misses uint64
+	now    func() time.Time
+}
+
+// New returns a new cache for size values, they expire after ttl.
+func New[V any](size int, ttl time.Duration) *Cache[V] {
+	return &Cache[V]{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+// Get returns a not expired value by key.
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	if item, ok := c.items[key]; ok {
+		e := item.Value.(*entry[V])
+
+		if c.now().Before(e.expires) {
+			c.hits++
+			c.order.MoveToFront(item)
+			return e.value, true
+		}
+
+		c.remove(item)
+	}
+
+	var empty V
+
+	c.misses++
+	return empty, false
+}
+
+// Set saves the value, the least recently used one is evicted if the cache is full.
+func (c *Cache[V]) Set(key string, value V) {
+	c.Lock()
+	defer c.Unlock()
+
+	expires := c.now().Add(c.ttl)
+
+	if item, ok := c.items[key]; ok {
+		e := item.Value.(*entry[V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(item)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[V]{key: key, value: value, expires: expires})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Stats returns the cache usage statistics.
+func (c *Cache[V]) Stats() Stats {
+	c.Lock()
+	defer c.Unlock()
+
+	return Stats{Hits: c.hits, Misses: c.misses, Size: c.order.Len()}
+}
+
+// remove deletes the cache item.
+func (c *Cache[V]) remove(item *list.Element) {
+	e := c.order.Remove(item).(*entry[V])
+	delete(c.items, e.key)
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	c := New[string](2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", "A")
+	c.Set("b", "B")
+
+	if v, ok := c.Get("a"); !ok || v != "A" {
+		t.Errorf("unexpected value: %q, %v", v, ok)
+	}
+
+	// "b" is the least recently used
+	c.Set("c", "C")
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("value is not evicted")
+	}
+
+	c.Set("a", "AA")
+	if v, ok := c.Get("a"); !ok || v != "AA" {
+		t.Errorf("unexpected updated value: %q, %v", v, ok)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("c"); ok {
+		t.Error("value is not expired")
+	}
+
+	expected := Stats{Hits: 2, Misses: 2, Size: 1}
+	if stats := c.Stats(); stats != expected {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,48 @@
+// Mockygpt is a mock YandexGPT chat generation API server for local development.
+//
+// Usage:
+//
+//	mockygpt -addr localhost:8081 -rules rules.json
+//
+// Then set "chat.url" config parameter to "http://localhost:8081/llm/v1alpha/chat".
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+func main() {
+	var (
+		addr       = flag.String("addr", "localhost:8081", "listen address")
+		rulesFile  = flag.String("rules", "", "JSON file with scripted replies")
+		latency    = flag.Duration("latency", 0, "default response latency")
+		chunkDelay = flag.Duration("chunk", 100*time.Millisecond, "delay between partial results")
+	)
+	flag.Parse()
+
+	s := &server{latency: *latency, chunkDelay: *chunkDelay}
+
+	if *rulesFile != "" {
+		rules, err := loadRules(*rulesFile)
+		if err != nil {
+			slog.Error("failed to load rules", "error", err)
+			os.Exit(1)
+		}
+		s.rules = rules
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/llm/v1alpha/chat", s)
+
+	srv := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	slog.Info("starting", "addr", *addr, "rules", len(s.rules))
+
+	if err := srv.ListenAndServe(); err != nil {
+		slog.Error("stopped", "error", err)
+		os.Exit(1)
+	}
+}
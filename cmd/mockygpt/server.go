@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+// rule is a scripted reply for matched prompts.
+type rule struct {
+	Match   string `json:"match"`   // regular expression of the last user's message, all messages match if it is empty
+	Reply   string `json:"reply"`   // answer text or error message
+	Status  int    `json:"status"`  // HTTP status code, 200 if it is empty
+	Latency string `json:"latency"` // optional delay before the response, for example "2s"
+	re      *regexp.Regexp
+	latency time.Duration
+}
+
+// loadRules reads scripted rules from JSON file.
+func loadRules(fileName string) ([]rule, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+
+	var rules []rule
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+
+	for i := range rules {
+		if err = rules[i].init(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+
+	return rules, nil
+}
+
+// init compiles the rule expression and parses its latency.
+func (r *rule) init() error {
+	var err error
+
+	if r.re, err = regexp.Compile(r.Match); err != nil {
+		return fmt.Errorf("failed to compile match: %w", err)
+	}
+
+	if r.Latency != "" {
+		if r.latency, err = time.ParseDuration(r.Latency); err != nil {
+			return fmt.Errorf("failed to parse latency: %w", err)
+		}
+	}
+
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+
+	return nil
+}
+
+// server is a mock chat generation API server.
+type server struct {
+	rules      []rule
+	latency    time.Duration // default delay before responses
+	chunkDelay time.Duration // delay between partial results
+}
+
+// errorResponse is an API error response body.
+type errorResponse struct {
+	Error struct {
+		GrpcCode   int    `json:"grpcCode"`
+		HTTPCode   int    `json:"httpCode"`
+		Message    string `json:"message"`
+		HTTPStatus string `json:"httpStatus"`
+	} `json:"error"`
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.fail(w, http.StatusMethodNotAllowed, "method is not allowed")
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Api-Key ") {
+		s.fail(w, http.StatusUnauthorized, "unknown api key")
+		return
+	}
+
+	request := &ygpt.TextGenerationChat{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		s.fail(w, http.StatusBadRequest, "failed to parse request: "+err.Error())
+		return
+	}
+
+	if len(request.Messages) == 0 {
+		s.fail(w, http.StatusBadRequest, "messages are empty")
+		return
+	}
+
+	text := request.Messages[len(request.Messages)-1].Text
+	reply, status, latency := s.reply(text)
+	slog.Info("request", "messages", len(request.Messages), "status", status, "latency", latency)
+
+	select {
+	case <-r.Context().Done():
+		return
+	case <-time.After(latency):
+	}
+
+	if status != http.StatusOK {
+		s.fail(w, status, reply)
+		return
+	}
+
+	tokens := countTokens(request, reply)
+	if request.GenerationOptions.PartialResults {
+		s.stream(w, r, reply, tokens)
+		return
+	}
+
+	s.write(w, reply, tokens)
+}
+
+// reply returns a scripted answer, its status and latency for the prompt text.
+// The prompt is echoed if no rules match it.
+func (s *server) reply(text string) (string, int, time.Duration) {
+	for _, r := range s.rules {
+		if r.re.MatchString(text) {
+			latency := r.latency
+			if latency == 0 {
+				latency = s.latency
+			}
+
+			return r.Reply, r.Status, latency
+		}
+	}
+
+	return "Mock answer: " + text, http.StatusOK, s.latency
+}
+
+// write sends a full chat response.
+func (s *server) write(w http.ResponseWriter, reply string, tokens int) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(chatResponse(reply, tokens)); err != nil {
+		slog.Error("failed to write response", "error", err)
+	}
+}
+
+// stream sends partial results as JSON lines, each of them contains the answer prefix.
+func (s *server) stream(w http.ResponseWriter, r *http.Request, reply string, tokens int) {
+	var (
+		words   = strings.Fields(reply)
+		encoder = json.NewEncoder(w)
+		flusher = w.(http.Flusher)
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	for i := range words {
+		if i > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(s.chunkDelay):
+			}
+		}
+
+		partial := strings.Join(words[:i+1], " ")
+		if err := encoder.Encode(chatResponse(partial, tokens-len(words)+i+1)); err != nil {
+			slog.Error("failed to write partial response", "error", err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// fail sends an error response.
+func (s *server) fail(w http.ResponseWriter, status int, message string) {
+	response := &errorResponse{}
+	response.Error.GrpcCode = 3
+	response.Error.HTTPCode = status
+	response.Error.Message = message
+	response.Error.HTTPStatus = http.StatusText(status)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("failed to write error response", "error", err)
+	}
+}
+
+// chatResponse returns a chat generation API response.
+func chatResponse(text string, tokens int) *ygpt.ChatResponse {
+	return &ygpt.ChatResponse{
+		Result: ygpt.ChatResult{
+			Message:   ygpt.Message{Role: ygpt.RoleAssistantRu, Text: text},
+			NumTokens: strconv.Itoa(tokens),
+		},
+	}
+}
+
+// countTokens returns an approximate number of request and answer tokens, one token per word.
+func countTokens(request *ygpt.TextGenerationChat, reply string) int {
+	n := len(strings.Fields(request.InstructionText)) + len(strings.Fields(reply))
+
+	for _, m := range request.Messages {
+		n += len(strings.Fields(m.Text))
+	}
+
+	return n
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+func TestLoadRules(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "rules.json")
+	data := `[{"match":"(?i)who","reply":"mock"},{"match":"limit","reply":"quota","status":429,"latency":"10ms"}]`
+
+	if err := os.WriteFile(fileName, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := loadRules(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(rules); n != 2 {
+		t.Fatalf("unexpected rules: %d", n)
+	}
+
+	if r := rules[0]; r.Status != http.StatusOK || r.re == nil {
+		t.Errorf("unexpected rule: %+v", r)
+	}
+
+	if r := rules[1]; r.Status != http.StatusTooManyRequests || r.latency.Milliseconds() != 10 {
+		t.Errorf("unexpected rule: %+v", r)
+	}
+
+	if err = os.WriteFile(fileName, []byte(`[{"match":"("}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = loadRules(fileName); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestServer(t *testing.T) {
+	rules := []rule{
+		{Match: "^who", Reply: "I am a mock"},
+		{Match: "^limit", Reply: "too many requests", Status: http.StatusTooManyRequests},
+	}
+
+	for i := range rules {
+		if err := rules[i].init(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := httptest.NewServer(&server{rules: rules})
+	defer s.Close()
+
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+		tokens   int64
+		status   string
+	}{
+		{name: "scripted", text: "who are you", expected: "I am a mock", tokens: 7},
+		{name: "echo", text: "hello", expected: "Mock answer: hello", tokens: 4},
+		{name: "error", text: "limit", status: "status code=429"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			request := &ygpt.ChatRequest{APIKey: "test-key", URL: s.URL, Text: tc.text}
+
+			resp, err := ygpt.GenerationChat(context.Background(), s.Client(), request)
+			if tc.status != "" {
+				if !errors.Is(err, ygpt.ErrChatGeneration) || !strings.Contains(err.Error(), tc.status) {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s := resp.String(); s != tc.expected {
+				t.Errorf("unexpected answer: %q", s)
+			}
+
+			if resp.Result.NumTokensInt != tc.tokens {
+				t.Errorf("unexpected tokens: %d", resp.Result.NumTokensInt)
+			}
+		})
+	}
+}
+
+func TestServerStream(t *testing.T) {
+	s := httptest.NewServer(&server{rules: nil})
+	defer s.Close()
+
+	body := `{"model":"general","generationOptions":{"partialResults":true},"messages":[{"role":"User","text":"a b"}]}`
+	req, err := http.NewRequest(http.MethodPost, s.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Api-Key test-key")
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if e := resp.Body.Close(); e != nil {
+			t.Error(e)
+		}
+	}()
+
+	var results []string
+	scanner := bufio.NewScanner(resp.Body)
+
+	for scanner.Scan() {
+		r := &ygpt.ChatResponse{}
+		if err = json.Unmarshal(scanner.Bytes(), r); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r.String())
+	}
+
+	expected := []string{"Mock", "Mock answer:", "Mock answer: a", "Mock answer: a b"}
+	if strings.Join(results, "|") != strings.Join(expected, "|") {
+		t.Errorf("unexpected partial results: %q", results)
+	}
+}
+
+func TestServerUnauthorized(t *testing.T) {
+	s := httptest.NewServer(&server{})
+	defer s.Close()
+
+	resp, err := s.Client().Post(s.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+}
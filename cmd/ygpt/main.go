@@ -0,0 +1,96 @@
+// Ygpt is a terminal client of YandexGPT chat, it uses the bot's configuration file.
+//
+// Usage:
+//
+//	ygpt -config config.json                  # interactive mode
+//	ygpt -config config.json "Who are you?"   # one-shot prompt
+//	cat main.go | ygpt -config config.json -instruction "Review the code"
+//
+// In the interactive mode a line ending with "\" is continued by the next one,
+// lines between `"""` delimiters are a single prompt. Commands: /reset, /exit.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/z0rr0/tgtpgybot/config"
+)
+
+// defaultHistorySize is a number of kept prompt-answer pairs if it is not set in the config.
+const defaultHistorySize = 10
+
+func main() {
+	var (
+		configFile  = flag.String("config", "config.json", "configuration file")
+		instruction = flag.String("instruction", "", "instruction text")
+	)
+	flag.Parse()
+
+	if err := run(*configFile, *instruction, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(1)
+	}
+}
+
+// run answers the prompt from arguments or piped stdin, otherwise it starts the interactive mode.
+func run(configFile, instruction string, args []string) error {
+	cfg, err := config.New(configFile)
+	if err != nil {
+		return err
+	}
+
+	// keep stdout for answers only
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	historySize := cfg.HistorySize
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
+
+	s := &session{
+		generate: func(ctx context.Context, prompt *config.Prompt) (*config.Answer, error) {
+			return cfg.Chat.Generation(ctx, prompt, 0)
+		},
+		instruction: instruction,
+		historySize: historySize,
+		timeout:     cfg.Timeout.Duration,
+		out:         os.Stdout,
+		info:        os.Stderr,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if len(args) > 0 {
+		return s.ask(ctx, strings.Join(args, " "))
+	}
+
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeCharDevice == 0 {
+		// piped input is a single prompt
+		data, e := io.ReadAll(os.Stdin)
+		if e != nil {
+			return e
+		}
+
+		text := strings.TrimSpace(string(data))
+		if text == "" {
+			return fmt.Errorf("empty prompt")
+		}
+
+		return s.ask(ctx, text)
+	}
+
+	return s.repl(ctx, os.Stdin)
+}
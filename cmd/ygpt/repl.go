@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/ygpt"
+)
+
+const (
+	// multiLineDelimiter starts and ends a multi-line prompt.
+	multiLineDelimiter = `"""`
+
+	// maxLineSize is a maximum length of an input line.
+	maxLineSize = 1 << 20 // 1 MB
+)
+
+// generator returns an answer for the prompt.
+type generator func(ctx context.Context, prompt *config.Prompt) (*config.Answer, error)
+
+// session is a terminal conversation.
+type session struct {
+	generate    generator
+	instruction string
+	historySize int // maximum number of kept prompt-answer pairs
+	timeout     time.Duration
+	history     []ygpt.Message
+	tokens      int64 // total used tokens
+	out         io.Writer
+	info        io.Writer // token usage and errors
+}
+
+// ask generates an answer for the text, it is printed with token usage.
+func (s *session) ask(ctx context.Context, text string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	prompt := &config.Prompt{Text: text, Instruction: s.instruction, History: s.history}
+
+	answer, err := s.generate(ctx, prompt)
+	if err != nil {
+		return err
+	}
+
+	s.tokens += answer.Tokens
+	s.remember(text, answer.Text)
+
+	if _, err = fmt.Fprintln(s.out, answer.Text); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.info, "[tokens: %d, total: %d]\n", answer.Tokens, s.tokens)
+	return err
+}
+
+// remember adds the prompt and answer to the history, only the latest pairs are kept.
+func (s *session) remember(text, answer string) {
+	if s.historySize <= 0 {
+		return
+	}
+
+	s.history = append(s.history, ygpt.Message{Role: ygpt.RoleUser, Text: text}, ygpt.Message{Role: ygpt.RoleAssistant, Text: answer})
+
+	if n := len(s.history) - 2*s.historySize; n > 0 {
+		s.history = s.history[n:]
+	}
+}
+
+// repl reads prompts from the input until its end, "/exit" command or interruption.
+func (s *session) repl(ctx context.Context, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	for {
+		if _, err := fmt.Fprint(s.info, "> "); err != nil {
+			return err
+		}
+
+		text, err := readPrompt(scanner)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		switch text {
+		case "":
+			continue
+		case "/exit":
+			return nil
+		case "/reset":
+			s.history = nil
+			if _, err = fmt.Fprintln(s.info, "[history is forgotten]"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err = s.ask(ctx, text); err != nil {
+			if ctx.Err() != nil {
+				return nil // interrupted
+			}
+
+			if _, e := fmt.Fprintf(s.info, "ERROR: %v\n", err); e != nil {
+				return e
+			}
+		}
+	}
+}
+
+// readPrompt returns a next prompt from the scanner.
+// A line ending with "\" is continued by the next one,
+// lines between `"""` delimiters are read as a single prompt.
+func readPrompt(scanner *bufio.Scanner) (string, error) {
+	var (
+		lines     []string
+		multiLine bool
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case multiLine:
+			if strings.TrimSpace(line) == multiLineDelimiter {
+				return strings.TrimSpace(strings.Join(lines, "\n")), nil
+			}
+			lines = append(lines, line)
+		case len(lines) == 0 && strings.TrimSpace(line) == multiLineDelimiter:
+			multiLine = true
+		case strings.HasSuffix(line, `\`):
+			lines = append(lines, strings.TrimSuffix(line, `\`))
+		default:
+			lines = append(lines, line)
+			return strings.TrimSpace(strings.Join(lines, "\n")), nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if len(lines) > 0 {
+		return strings.TrimSpace(strings.Join(lines, "\n")), nil
+	}
+
+	return "", io.EOF
+}
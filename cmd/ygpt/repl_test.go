@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/tgtpgybot/config"
+)
+
+func TestReadPrompt(t *testing.T) {
+	input := "first\n\nsecond \\\nline\n\"\"\"\nmulti\n\n  line\n\"\"\"\nlast"
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	expected := []string{"first", "", "second \nline", "multi\n\n  line", "last"}
+
+	for _, e := range expected {
+		text, err := readPrompt(scanner)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if text != e {
+			t.Errorf("unexpected prompt: %q, expected %q", text, e)
+		}
+	}
+
+	if _, err := readPrompt(scanner); !errors.Is(err, io.EOF) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSessionREPL(t *testing.T) {
+	var prompts []*config.Prompt
+
+	out, info := &bytes.Buffer{}, &bytes.Buffer{}
+	s := &session{
+		generate: func(ctx context.Context, prompt *config.Prompt) (*config.Answer, error) {
+			prompts = append(prompts, prompt)
+			if prompt.Text == "fail" {
+				return nil, errors.New("test error")
+			}
+			return &config.Answer{Text: "answer: " + prompt.Text, Tokens: 10}, nil
+		},
+		instruction: "be brief",
+		historySize: 1,
+		timeout:     time.Second,
+		out:         out,
+		info:        info,
+	}
+
+	input := "a\nb\nfail\n/reset\nc\n/exit\nd\n"
+	if err := s.repl(context.Background(), strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(prompts); n != 4 {
+		t.Fatalf("unexpected prompts: %d", n)
+	}
+
+	// only one prompt-answer pair is kept, it is forgotten by /reset
+	expectedHistory := []int{0, 2, 2, 0}
+	for i, p := range prompts {
+		if len(p.History) != expectedHistory[i] {
+			t.Errorf("unexpected history of prompt %d: %v", i, p.History)
+		}
+
+		if p.Instruction != "be brief" {
+			t.Errorf("unexpected instruction: %q", p.Instruction)
+		}
+	}
+
+	if prompts[1].History[0].Text != "a" {
+		t.Errorf("unexpected history: %v", prompts[1].History)
+	}
+
+	if s := out.String(); s != "answer: a\nanswer: b\nanswer: c\n" {
+		t.Errorf("unexpected output: %q", s)
+	}
+
+	for _, expected := range []string{"[tokens: 10, total: 30]", "ERROR: test error", "[history is forgotten]"} {
+		if !strings.Contains(info.String(), expected) {
+			t.Errorf("expected %q in %q", expected, info.String())
+		}
+	}
+}
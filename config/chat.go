@@ -3,23 +3,49 @@ package config
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
 
+	"github.com/z0rr0/tgtpgybot/speechkit"
+	"github.com/z0rr0/tgtpgybot/tracing"
+	"github.com/z0rr0/tgtpgybot/vision"
+	"github.com/z0rr0/tgtpgybot/webpage"
 	"github.com/z0rr0/tgtpgybot/ygpt"
 )
 
-// Chat is a chat generation API configuration.
+// Speech is a speech recognition and synthesis configuration.
+type Speech struct {
+	Lang  string  `json:"lang"`
+	Voice string  `json:"voice"`
+	Speed float64 `json:"speed"`
+}
+
+// Chat is a chat generation, speech and vision API configuration.
 type Chat struct {
-	APIKey string       `json:"api_key"`
-	Proxy  string       `json:"proxy"`
-	URL    string       `json:"-"`
-	Client *http.Client `json:"-"`
+	APIKey        string       `json:"api_key"`
+	Proxy         string       `json:"proxy"`
+	Speech        Speech       `json:"speech"`
+	Temperature   float64      `json:"temperature"`
+	MaxTokens     int64        `json:"max_tokens"`
+	ContextTokens int64        `json:"context_tokens"` // model context size, prompts and answers must fit it
+	Tokenize      bool         `json:"tokenize"`       // count tokens by the tokenizer API instead of the estimation
+	Moderation    Moderation   `json:"moderation"`
+	URL           string       `json:"url"` // optional chat generation API URL, for example a mock server
+	TokenizeURL   string       `json:"-"`
+	RecognizeURL  string       `json:"-"`
+	SynthesizeURL string       `json:"-"`
+	OCRURL        string       `json:"-"`
+	Client        *http.Client `json:"-"`
 }
 
-// init creates a new HTTP client and sets the chat generation API URL.
+// init creates the moderation filter, a new traced HTTP client and sets the chat generation API URL.
 func (chat *Chat) init() error {
+	if err := chat.Moderation.init(); err != nil {
+		return fmt.Errorf("moderation: %w", err)
+	}
+
 	if chat.Client != nil {
 		return nil
 	}
@@ -33,28 +59,234 @@ func (chat *Chat) init() error {
 		if err != nil {
 			return fmt.Errorf("failed to parse proxy URL: %w", err)
 		}
-		chat.Client = &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		chat.Client = &http.Client{Transport: tracing.Transport(&http.Transport{Proxy: http.ProxyURL(proxyURL)})}
 	} else {
-		chat.Client = &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}}
+		chat.Client = &http.Client{Transport: tracing.Transport(&http.Transport{Proxy: http.ProxyFromEnvironment})}
+	}
+
+	if chat.URL == "" {
+		chat.URL = ygpt.ChatURL
 	}
 
-	chat.URL = ygpt.ChatURL
+	if chat.TokenizeURL == "" {
+		chat.TokenizeURL = ygpt.TokenizeURL
+	}
+
+	chat.RecognizeURL = speechkit.RecognizeURL
+	chat.SynthesizeURL = speechkit.SynthesizeURL
+	chat.OCRURL = vision.OCRURL
 	return nil
 }
 
-// Generation generates a new GPT text response.
-func (chat *Chat) Generation(ctx context.Context, text string, messageID int) (string, error) {
-	request := &ygpt.ChatRequest{
+// Prompt is a chat generation request.
+type Prompt struct {
+	Text        string
+	Instruction string         // optional instruction text
+	History     []ygpt.Message // optional previous messages
+}
+
+// Answer is a chat generation result.
+type Answer struct {
+	Text    string
+	Tokens  int64
+	Model   ygpt.Model
+	Options ygpt.GenerationOptions
+	Cached  bool // the answer is returned from the cache
+}
+
+// GenerationOptions returns the chat generation options, empty values are replaced by defaults.
+func (chat *Chat) GenerationOptions() ygpt.GenerationOptions {
+	request := &ygpt.ChatRequest{Options: ygpt.GenerationOptions{Temperature: chat.Temperature, MaxTokens: chat.MaxTokens}}
+	return request.GenerationOptions()
+}
+
+// InputBudget returns a maximum number of prompt tokens,
+// the context size is reduced by the maximum number of answer tokens.
+func (chat *Chat) InputBudget() int64 {
+	contextTokens := chat.ContextTokens
+	if contextTokens <= 0 {
+		contextTokens = ygpt.DefaultContextTokens
+	}
+
+	return contextTokens - chat.GenerationOptions().MaxTokens
+}
+
+// TokenCount returns a number of the text tokens by the tokenizer API.
+// The text is checked by the moderation filter before it is sent, denied texts are not counted.
+func (chat *Chat) TokenCount(ctx context.Context, text string) (int64, error) {
+	if f := chat.Moderation.Filter; f != nil {
+		var err error
+		if text, err = f.Prompt(text); err != nil {
+			return 0, fmt.Errorf("failed to moderate: %w", err)
+		}
+	}
+
+	request := &ygpt.TokenizeRequest{
 		APIKey: chat.APIKey,
-		URL:    chat.URL,
+		URL:    chat.TokenizeURL,
 		Text:   text,
 	}
 
+	resp, err := ygpt.Tokenize(ctx, chat.Client, request)
+	if err != nil {
+		return 0, fmt.Errorf("failed to tokenize: %w", err)
+	}
+
+	return int64(len(resp.Tokens)), nil
+}
+
+// Generation generates a new GPT text response.
+// The prompt and answer are checked by the moderation filter if it is set.
+func (chat *Chat) Generation(ctx context.Context, prompt *Prompt, messageID int) (*Answer, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ygpt.generation")
+	defer span.End()
+
+	prompt, err := chat.ModeratePrompt(prompt)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	request := &ygpt.ChatRequest{
+		APIKey:      chat.APIKey,
+		URL:         chat.URL,
+		Text:        prompt.Text,
+		Instruction: prompt.Instruction,
+		Messages:    prompt.History,
+		Options:     chat.GenerationOptions(),
+	}
+
+	span.SetAttributes(
+		tracing.AttrModel.String(string(ygpt.ModelGeneral)),
+		tracing.AttrTemperature.Float64(request.Options.Temperature),
+		tracing.AttrMaxTokens.Int64(request.Options.MaxTokens),
+	)
+
 	resp, err := ygpt.GenerationChat(ctx, chat.Client, request)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate: %w", err)
+		tracing.Fail(span, err)
+		return nil, fmt.Errorf("failed to generate: %w", err)
 	}
 
+	span.SetAttributes(tracing.AttrTokens.Int64(resp.Result.NumTokensInt))
 	slog.Info("chat generation", "id", messageID, "tokens", resp.Result.NumTokensInt)
+
+	if f := chat.Moderation.Filter; f != nil {
+		if err = f.Answer(resp.String()); err != nil {
+			tracing.Fail(span, err)
+			slog.Warn("chat generation refused", "id", messageID)
+			return nil, fmt.Errorf("failed to generate: %w", err)
+		}
+	}
+
+	return &Answer{
+		Text:    resp.String(),
+		Tokens:  resp.Result.NumTokensInt,
+		Model:   ygpt.ModelGeneral,
+		Options: request.Options,
+	}, nil
+}
+
+// ModeratePrompt returns a copy of the prompt with redacted texts,
+// an error is returned if the prompt is denied by the filter.
+// Redacted texts are not changed by the repeated moderation.
+func (chat *Chat) ModeratePrompt(prompt *Prompt) (*Prompt, error) {
+	f := chat.Moderation.Filter
+	if f == nil {
+		return prompt, nil
+	}
+
+	text, err := f.Prompt(prompt.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to moderate: %w", err)
+	}
+
+	instruction, err := f.Prompt(prompt.Instruction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to moderate instruction: %w", err)
+	}
+
+	history := make([]ygpt.Message, len(prompt.History))
+	for i, m := range prompt.History {
+		if m.Text, err = f.Prompt(m.Text); err != nil {
+			return nil, fmt.Errorf("failed to moderate history: %w", err)
+		}
+		history[i] = m
+	}
+
+	return &Prompt{Text: text, Instruction: instruction, History: history}, nil
+}
+
+// Recognition returns a recognized text of OGG/Opus audio data.
+func (chat *Chat) Recognition(ctx context.Context, audio io.Reader, messageID int) (string, error) {
+	request := &speechkit.RecognizeRequest{
+		APIKey: chat.APIKey,
+		URL:    chat.RecognizeURL,
+		Lang:   chat.Speech.Lang,
+		Audio:  audio,
+	}
+
+	resp, err := speechkit.Recognize(ctx, chat.Client, request)
+	if err != nil {
+		return "", fmt.Errorf("failed to recognize: %w", err)
+	}
+
+	slog.Info("speech recognition", "id", messageID, "length", len(resp.Result))
 	return resp.String(), nil
 }
+
+// Synthesis returns OGG/Opus audio data of the synthesized text.
+func (chat *Chat) Synthesis(ctx context.Context, text string, messageID int) ([]byte, error) {
+	request := &speechkit.SynthesizeRequest{
+		APIKey: chat.APIKey,
+		URL:    chat.SynthesizeURL,
+		Text:   text,
+		Lang:   chat.Speech.Lang,
+		Voice:  chat.Speech.Voice,
+		Speed:  chat.Speech.Speed,
+	}
+
+	data, err := speechkit.Synthesize(ctx, chat.Client, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to synthesize: %w", err)
+	}
+
+	slog.Info("speech synthesis", "id", messageID, "size", len(data))
+	return data, nil
+}
+
+// TextRecognition returns a recognized text of JPEG image data.
+func (chat *Chat) TextRecognition(ctx context.Context, image []byte, messageID int) (string, error) {
+	request := &vision.OCRRequest{
+		APIKey: chat.APIKey,
+		URL:    chat.OCRURL,
+		Image:  image,
+	}
+
+	resp, err := vision.RecognizeText(ctx, chat.Client, request)
+	if err != nil {
+		return "", fmt.Errorf("failed to recognize text: %w", err)
+	}
+
+	slog.Info("text recognition", "id", messageID, "length", len(resp.String()))
+	return resp.String(), nil
+}
+
+// Page returns a fetched web page with its readable text.
+func (chat *Chat) Page(ctx context.Context, pageURL string, pages *Pages, messageID int) (*webpage.Page, error) {
+	request := &webpage.Request{
+		URL:      pageURL,
+		MaxSize:  pages.MaxSize,
+		MaxChars: pages.MaxChars,
+		Allow:    pages.Allow,
+		Deny:     pages.Deny,
+	}
+
+	page, err := webpage.Fetch(ctx, chat.Client, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch page: %w", err)
+	}
+
+	slog.Info("page fetched", "id", messageID, "url", page.URL, "length", len(page.Text), "truncated", page.Truncated)
+	return page, nil
+}
@@ -3,11 +3,15 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/z0rr0/tgtpgybot/moderation"
+	"github.com/z0rr0/tgtpgybot/rotate"
 )
 
 // TimeDuration is a wrapper for time.Duration to marshal/unmarshal to/from JSON
@@ -41,15 +45,235 @@ func (d *TimeDuration) String() string {
 	return d.Duration.String()
 }
 
+// Documents is an uploaded documents configuration.
+type Documents struct {
+	MaxSize      int64 `json:"max_size"`      // maximum file size in bytes
+	ChunkSize    int   `json:"chunk_size"`    // maximum chunk length in characters
+	ContextLimit int   `json:"context_limit"` // maximum document context length in characters
+}
+
+// Default uploaded documents limits.
+const (
+	defaultDocumentMaxSize      = 1 << 20 // 1 MB
+	defaultDocumentChunkSize    = 1000
+	defaultDocumentContextLimit = 6000
+)
+
+// init sets default values of empty limits.
+func (d *Documents) init() {
+	if d.MaxSize <= 0 {
+		d.MaxSize = defaultDocumentMaxSize
+	}
+
+	if d.ChunkSize <= 0 {
+		d.ChunkSize = defaultDocumentChunkSize
+	}
+
+	if d.ContextLimit <= 0 {
+		d.ContextLimit = defaultDocumentContextLimit
+	}
+}
+
+// Pages is a web pages summarization configuration.
+type Pages struct {
+	MaxSize  int64    `json:"max_size"`  // maximum page size in bytes
+	MaxChars int      `json:"max_chars"` // maximum page text length in characters to summarize
+	Allow    []string `json:"allow"`     // allowed domains, all are allowed if it is empty
+	Deny     []string `json:"deny"`      // denied domains
+}
+
+// Default web pages limits.
+const (
+	defaultPageMaxSize  = 2 << 20 // 2 MB
+	defaultPageMaxChars = 6000
+)
+
+// init sets default values of empty limits.
+func (p *Pages) init() {
+	if p.MaxSize <= 0 {
+		p.MaxSize = defaultPageMaxSize
+	}
+
+	if p.MaxChars <= 0 {
+		p.MaxChars = defaultPageMaxChars
+	}
+}
+
+// Audit is an audit log of generations configuration, it is disabled if the path is empty.
+type Audit struct {
+	Path       string       `json:"path"`        // JSONL file path
+	MaxSize    int64        `json:"max_size"`    // maximum file size in bytes before rotation
+	MaxAge     TimeDuration `json:"max_age"`     // maximum file age before rotation, it is not limited if empty
+	MaxBackups int          `json:"max_backups"` // maximum number of rotated files
+	Redact     bool         `json:"redact"`      // do not write prompt texts, only metadata
+}
+
+// Default limits of rotated files.
+const (
+	defaultRotationMaxSize    = 10 << 20 // 10 MB
+	defaultRotationMaxBackups = 10
+)
+
+// init sets default values of empty limits.
+func (a *Audit) init() {
+	if a.MaxSize <= 0 {
+		a.MaxSize = defaultRotationMaxSize
+	}
+
+	if a.MaxBackups <= 0 {
+		a.MaxBackups = defaultRotationMaxBackups
+	}
+}
+
+// Cache is a generation answers cache configuration, it is disabled if the size is not positive.
+type Cache struct {
+	Size int          `json:"size"` // maximum number of cached answers
+	TTL  TimeDuration `json:"ttl"`  // answer expiration time
+}
+
+// defaultCacheTTL is a default expiration time of cached answers.
+const defaultCacheTTL = time.Hour
+
+// init sets default values of empty parameters.
+func (c *Cache) init() {
+	if c.TTL.Duration <= 0 {
+		c.TTL.Duration = defaultCacheTTL
+	}
+}
+
+// Tracing is an OpenTelemetry tracing configuration, it is disabled if the endpoint is empty.
+type Tracing struct {
+	Endpoint    string `json:"endpoint"`     // OTLP/HTTP traces URL, for example "http://localhost:4318/v1/traces"
+	ServiceName string `json:"service_name"` // service name of exported spans
+}
+
+// defaultServiceName is a default service name of exported spans.
+const defaultServiceName = "tgtpgybot"
+
+// init sets default values of empty parameters.
+func (t *Tracing) init() {
+	if t.ServiceName == "" {
+		t.ServiceName = defaultServiceName
+	}
+}
+
+// Moderation is a configuration of prompts and answers filters.
+type Moderation struct {
+	DenyWords    []string           `json:"deny_words"`    // case-insensitive denied words and phrases
+	DenyPatterns []string           `json:"deny_patterns"` // denied regular expressions
+	PII          moderation.Action  `json:"pii"`           // "redact", "block" or empty to skip personal data checks
+	PIITypes     []string           `json:"pii_types"`     // "phone", "email", "card", "api_key", all if empty
+	Refusals     []string           `json:"refusals"`      // additional beginnings of model refusal answers
+	Filter       *moderation.Filter `json:"-"`
+}
+
+// init creates the filter.
+func (m *Moderation) init() error {
+	if m.Filter != nil {
+		return nil
+	}
+
+	filter, err := moderation.New(&moderation.Options{
+		DenyWords:    m.DenyWords,
+		DenyPatterns: m.DenyPatterns,
+		PII:          m.PII,
+		PIITypes:     m.PIITypes,
+		Refusals:     m.Refusals,
+	})
+	if err != nil {
+		return err
+	}
+
+	m.Filter = filter
+	return nil
+}
+
+// SecretsPolicy is a way to handle secrets found in prompts.
+type SecretsPolicy string
+
+// Supported secrets policies.
+const (
+	SecretsMask    SecretsPolicy = "mask"    // replace secrets by placeholders
+	SecretsConfirm SecretsPolicy = "confirm" // ask the user to send, mask or cancel the prompt
+	SecretsOff     SecretsPolicy = "off"     // do not check prompts
+)
+
+// Secrets is a configuration of the secrets leakage guard of prompts.
+type Secrets struct {
+	Policy SecretsPolicy           `json:"policy"` // default policy, "mask" if it is empty
+	Chats  map[int64]SecretsPolicy `json:"chats"`  // policies of chats by IDs
+}
+
+// init sets the default policy and validates all policies.
+func (s *Secrets) init() error {
+	if s.Policy == "" {
+		s.Policy = SecretsMask
+	}
+
+	if err := s.Policy.validate(); err != nil {
+		return err
+	}
+
+	for chatID, policy := range s.Chats {
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("chat %d: %w", chatID, err)
+		}
+	}
+
+	return nil
+}
+
+// ChatPolicy returns the secrets policy of the chat.
+func (s *Secrets) ChatPolicy(chatID int64) SecretsPolicy {
+	if policy, ok := s.Chats[chatID]; ok {
+		return policy
+	}
+
+	if s.Policy == "" {
+		return SecretsMask
+	}
+
+	return s.Policy
+}
+
+// validate returns an error if the policy is unknown.
+func (p SecretsPolicy) validate() error {
+	switch p {
+	case SecretsMask, SecretsConfirm, SecretsOff:
+		return nil
+	}
+
+	return fmt.Errorf("unknown secrets policy %q", p)
+}
+
 // Config is main config structure.
 type Config struct {
-	Token      string       `json:"token"`
-	Timeout    TimeDuration `json:"timeout"`
-	DebugLevel string       `json:"debug_level"`
-	Users      []int64      `json:"users"`
-	Chat       Chat         `json:"chat"`
-	VerboseBot bool         `json:"-"`
-	Offline    bool         `json:"-"`
+	Token         string            `json:"token"`
+	TelegramURL   string            `json:"telegram_url"` // optional Bot API server URL, for example a local or fake one
+	Timeout       TimeDuration      `json:"timeout"`
+	ForwardWindow TimeDuration      `json:"forward_window"`
+	HistorySize   int               `json:"history_size"` // number of conversation context turns per chat, 0 disables history
+	DebugLevel    string            `json:"debug_level"`
+	LogFormat     string            `json:"log_format"`      // "text" (default) or "json"
+	LogOutput     string            `json:"log_output"`      // "stdout" (default), "stderr" or a file path
+	LogSource     bool              `json:"log_source"`      // add source code position to log records
+	LogMaxSize    int64             `json:"log_max_size"`    // maximum log file size in bytes before rotation
+	LogMaxBackups int               `json:"log_max_backups"` // maximum number of rotated log files
+	Users         []int64           `json:"users"`
+	Admins        []int64           `json:"admins"` // users allowed to run admin commands
+	Chat          Chat              `json:"chat"`
+	Documents     Documents         `json:"documents"`
+	Templates     map[string]string `json:"templates"`
+	Pages         Pages             `json:"pages"`
+	Audit         Audit             `json:"audit"`
+	Cache         Cache             `json:"cache"`
+	Tracing       Tracing           `json:"tracing"`
+	Secrets       Secrets           `json:"secrets"`
+	VerboseBot    bool              `json:"-"`
+	Offline       bool              `json:"-"`
+	LogLevel      *slog.LevelVar    `json:"-"` // runtime logging level
+	Version       string            `json:"-"` // build version for admin statistics
+	Revision      string            `json:"-"` // build revision for admin statistics
 }
 
 // New creates new config from file.
@@ -73,6 +297,20 @@ func New(configFile string) (*Config, error) {
 		return nil, fmt.Errorf("config init GPT: %w", err)
 	}
 
+	c.Documents.init()
+	c.Pages.init()
+	c.Audit.init()
+	c.Cache.init()
+	c.Tracing.init()
+
+	if err = c.Secrets.init(); err != nil {
+		return nil, fmt.Errorf("config init secrets: %w", err)
+	}
+
+	if err = c.initTemplates(); err != nil {
+		return nil, fmt.Errorf("config init templates: %w", err)
+	}
+
 	if err = c.initLogger(); err != nil {
 		return nil, fmt.Errorf("config init logger: %w", err)
 	}
@@ -97,13 +335,57 @@ func (c *Config) initLogger() error {
 		return fmt.Errorf("unknown debug level: %q", c.DebugLevel)
 	}
 
-	handleOps := &slog.HandlerOptions{Level: level}
-	logger := slog.New(slog.NewTextHandler(os.Stdout, handleOps))
+	w, err := c.logOutput()
+	if err != nil {
+		return err
+	}
 
-	slog.SetDefault(logger)
+	c.LogLevel = level
+
+	var (
+		handler   slog.Handler
+		handleOps = &slog.HandlerOptions{Level: level, AddSource: c.LogSource}
+	)
+
+	switch c.LogFormat {
+	case "", "text":
+		handler = slog.NewTextHandler(w, handleOps)
+	case "json":
+		handler = slog.NewJSONHandler(w, handleOps)
+	default:
+		return fmt.Errorf("unknown log format: %q", c.LogFormat)
+	}
+
+	slog.SetDefault(slog.New(handler))
 	return nil
 }
 
+// logOutput returns a writer of logs: stdout, stderr or a rotated file.
+func (c *Config) logOutput() (io.Writer, error) {
+	switch c.LogOutput {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+
+	maxSize, maxBackups := c.LogMaxSize, c.LogMaxBackups
+	if maxSize <= 0 {
+		maxSize = defaultRotationMaxSize
+	}
+
+	if maxBackups <= 0 {
+		maxBackups = defaultRotationMaxBackups
+	}
+
+	f, err := rotate.New(c.LogOutput, maxSize, 0, maxBackups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	return f, nil
+}
+
 // LogValue implements slog.Value interface.
 func (c *Config) LogValue() slog.Value {
 	var b strings.Builder
@@ -118,7 +400,20 @@ func (c *Config) LogValue() slog.Value {
 	b.WriteString(hideParam(c.Chat.APIKey) + ", ")
 
 	b.WriteString("chat.proxy=")
-	b.WriteString(hideParam(c.Chat.Proxy))
+	b.WriteString(hideParam(c.Chat.Proxy) + ", ")
+
+	b.WriteString(fmt.Sprintf("history_size=%d, ", c.HistorySize))
+	b.WriteString(fmt.Sprintf("chat.context_tokens=%d, chat.tokenize=%v, ", c.Chat.ContextTokens, c.Chat.Tokenize))
+
+	// denied words and patterns can be sensitive, so only their numbers are logged
+	m := c.Chat.Moderation
+	b.WriteString(fmt.Sprintf("chat.moderation.deny_words=%d, chat.moderation.deny_patterns=%d, ", len(m.DenyWords), len(m.DenyPatterns)))
+	b.WriteString(fmt.Sprintf("chat.moderation.pii=%q, chat.moderation.pii_types=%v, ", m.PII, m.PIITypes))
+
+	b.WriteString(fmt.Sprintf("pages.max_size=%d, pages.max_chars=%d, ", c.Pages.MaxSize, c.Pages.MaxChars))
+	b.WriteString(fmt.Sprintf("pages.allow=%v, pages.deny=%v, ", c.Pages.Allow, c.Pages.Deny))
+	b.WriteString(fmt.Sprintf("cache.size=%d, cache.ttl=%s, ", c.Cache.Size, c.Cache.TTL.String()))
+	b.WriteString(fmt.Sprintf("secrets.policy=%s, secrets.chats=%d", c.Secrets.Policy, len(c.Secrets.Chats)))
 
 	return slog.StringValue(b.String())
 }
@@ -2,10 +2,19 @@ package config
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/z0rr0/tgtpgybot/moderation"
+	"github.com/z0rr0/tgtpgybot/ygpt"
 )
 
 const tmpConfig = "/tmp/tgtpgybot_config_test.json"
@@ -25,7 +34,11 @@ func TestNew(t *testing.T) {
 		t.Fatal("config is nil")
 	}
 
-	expected := "token=****, timeout=1m0s, debug_level=info, chat.api_key=****, chat.proxy=empty"
+	expected := "token=****, timeout=1m0s, debug_level=info, chat.api_key=****, chat.proxy=empty, " +
+		"history_size=10, chat.context_tokens=8000, chat.tokenize=false, " +
+		"chat.moderation.deny_words=0, chat.moderation.deny_patterns=0, chat.moderation.pii=\"redact\", chat.moderation.pii_types=[], " +
+		"pages.max_size=2097152, pages.max_chars=6000, pages.allow=[], pages.deny=[localhost], " +
+		"cache.size=0, cache.ttl=1h0m0s, secrets.policy=mask, secrets.chats=0"
 
 	logValue := cfg.LogValue()
 	if s := logValue.String(); s != expected {
@@ -51,6 +64,61 @@ func TestLoggerInit(t *testing.T) {
 		if err = cfg.initLogger(); err != nil {
 			t.Error(err)
 		}
+
+		if l := cfg.LogLevel.Level().String(); !strings.EqualFold(l, level) {
+			t.Errorf("unexpected log level: %q", l)
+		}
+	}
+}
+
+func TestInitLoggerOutput(t *testing.T) {
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+
+	logFile := filepath.Join(t.TempDir(), "bot.log")
+	testCases := []struct {
+		name   string
+		format string
+		output string
+		err    bool
+	}{
+		{name: "default"},
+		{name: "stderr", format: "text", output: "stderr"},
+		{name: "json", format: "json", output: "stdout"},
+		{name: "file", format: "json", output: logFile},
+		{name: "badFormat", format: "xml", err: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{DebugLevel: "info", LogFormat: tc.format, LogOutput: tc.output, LogSource: true}
+
+			err := cfg.initLogger()
+			if tc.err {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	slog.Info("test", "key", "value")
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{`"msg":"test"`, `"key":"value"`, `"source":{`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected %q in %q", expected, data)
+		}
 	}
 }
 
@@ -74,6 +142,22 @@ func TestChatInit(t *testing.T) {
 		t.Error(err)
 	}
 
+	if cfg.Chat.URL != ygpt.ChatURL || cfg.Chat.TokenizeURL != ygpt.TokenizeURL {
+		t.Errorf("unexpected default URLs: %q, %q", cfg.Chat.URL, cfg.Chat.TokenizeURL)
+	}
+
+	mockURL, tokenizeURL := "http://localhost:8081/llm/v1alpha/chat", "http://localhost:8081/llm/v1alpha/tokenize"
+	cfg.Chat.Client = nil
+	cfg.Chat.URL, cfg.Chat.TokenizeURL = mockURL, tokenizeURL
+
+	if err = cfg.Chat.init(); err != nil {
+		t.Error(err)
+	}
+
+	if cfg.Chat.URL != mockURL || cfg.Chat.TokenizeURL != tokenizeURL {
+		t.Errorf("unexpected mock URLs: %q, %q", cfg.Chat.URL, cfg.Chat.TokenizeURL)
+	}
+
 	cfg.Chat.Client = nil
 	cfg.Chat.APIKey = ""
 
@@ -97,12 +181,298 @@ func TestChatGeneration(t *testing.T) {
 	expected := "Меня зовут Алиса"
 	ctx := context.Background()
 
-	value, err := chat.Generation(ctx, "Кто ты?", 1)
+	value, err := chat.Generation(ctx, &Prompt{Text: "Кто ты?"}, 1)
 	if err != nil {
 		t.Fatalf("failed to get completion: %v", err)
 	}
 
+	if value.Text != expected {
+		t.Errorf("completion value is not equal: %q", value.Text)
+	}
+
+	if value.Tokens != 20 {
+		t.Errorf("tokens number is not equal: %d", value.Tokens)
+	}
+
+	if value.Options.MaxTokens != ygpt.DefaultMaxTokens {
+		t.Errorf("max tokens is not equal: %d", value.Options.MaxTokens)
+	}
+}
+
+func TestChatGenerationModeration(t *testing.T) {
+	var requests int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		request := &ygpt.TextGenerationChat{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		for _, m := range request.Messages {
+			if strings.Contains(m.Text, "@example.com") {
+				t.Errorf("email is not redacted: %q", m.Text)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"result":{"message":{"role":"Ассистент","text":"Я не могу обсуждать эту тему."},"num_tokens":"5"}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	chat := &Chat{
+		APIKey:     "test-key",
+		URL:        s.URL,
+		Client:     s.Client(),
+		Moderation: Moderation{DenyWords: []string{"forbidden"}, PII: moderation.ActionRedact},
+	}
+	if err := chat.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	history := []ygpt.Message{{Role: ygpt.RoleUser, Text: "write to test@example.com"}}
+
+	_, err := chat.Generation(ctx, &Prompt{Text: "a forbidden topic"}, 1)
+	if !errors.Is(err, moderation.ErrDenied) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if requests != 0 {
+		t.Errorf("denied prompt is sent")
+	}
+
+	_, err = chat.Generation(ctx, &Prompt{Text: "mail me at me@example.com", History: history}, 2)
+	if !errors.Is(err, moderation.ErrRefusal) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("unexpected requests: %d", requests)
+	}
+
+	if history[0].Text != "write to test@example.com" {
+		t.Errorf("history is changed: %q", history[0].Text)
+	}
+
+	chat.Moderation = Moderation{Filter: nil, PII: "hide"}
+	if err = chat.init(); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestChatRecognition(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if _, err := fmt.Fprint(w, `{"result":"Кто ты?"}`); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	chat := &Chat{APIKey: "test-key", RecognizeURL: s.URL, Client: s.Client()}
+	expected := "Кто ты?"
+
+	value, err := chat.Recognition(context.Background(), strings.NewReader("audio"), 1)
+	if err != nil {
+		t.Fatalf("failed to recognize: %v", err)
+	}
+
 	if value != expected {
-		t.Errorf("completion value is not equal: %q", value)
+		t.Errorf("recognition value is not equal: %q", value)
+	}
+}
+
+func TestChatSynthesis(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if voice := r.FormValue("voice"); voice != "alena" {
+			t.Errorf("unexpected voice: %q", voice)
+		}
+
+		if _, err := fmt.Fprint(w, "OggS"); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	chat := &Chat{APIKey: "test-key", SynthesizeURL: s.URL, Client: s.Client(), Speech: Speech{Voice: "alena"}}
+
+	data, err := chat.Synthesis(context.Background(), "Меня зовут Алиса", 1)
+	if err != nil {
+		t.Fatalf("failed to synthesize: %v", err)
+	}
+
+	if d := string(data); d != "OggS" {
+		t.Errorf("synthesis data is not equal: %q", d)
+	}
+}
+
+func TestDocumentsInit(t *testing.T) {
+	d := &Documents{ChunkSize: 500}
+	d.init()
+
+	expected := Documents{MaxSize: defaultDocumentMaxSize, ChunkSize: 500, ContextLimit: defaultDocumentContextLimit}
+	if *d != expected {
+		t.Errorf("unexpected documents config: %#v", d)
+	}
+}
+
+func TestChatTextRecognition(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if _, err := fmt.Fprint(w, `{"result":{"textAnnotation":{"fullText":"panic: nil map"}}}`); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	chat := &Chat{APIKey: "test-key", OCRURL: s.URL, Client: s.Client()}
+	expected := "panic: nil map"
+
+	value, err := chat.TextRecognition(context.Background(), []byte("image"), 1)
+	if err != nil {
+		t.Fatalf("failed to recognize text: %v", err)
+	}
+
+	if value != expected {
+		t.Errorf("text recognition value is not equal: %q", value)
+	}
+}
+
+func TestChatPage(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+
+		if _, err := fmt.Fprint(w, "page text"); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	chat := &Chat{APIKey: "test-key", Client: s.Client()}
+	pages := &Pages{}
+	pages.init()
+
+	page, err := chat.Page(context.Background(), s.URL, pages, 1)
+	if err != nil {
+		t.Fatalf("failed to fetch page: %v", err)
+	}
+
+	if page.Text != "page text" {
+		t.Errorf("page text is not equal: %q", page.Text)
+	}
+
+	pages.Deny = []string{"127.0.0.1"}
+	if _, err = chat.Page(context.Background(), s.URL, pages, 1); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestSecretsInit(t *testing.T) {
+	s := &Secrets{Chats: map[int64]SecretsPolicy{-100: SecretsConfirm, 2: SecretsOff}}
+	if err := s.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		chatID   int64
+		expected SecretsPolicy
+	}{
+		{chatID: 1, expected: SecretsMask},
+		{chatID: 2, expected: SecretsOff},
+		{chatID: -100, expected: SecretsConfirm},
+	}
+
+	for _, tc := range testCases {
+		if policy := s.ChatPolicy(tc.chatID); policy != tc.expected {
+			t.Errorf("unexpected policy of chat %d: %q", tc.chatID, policy)
+		}
+	}
+
+	s = &Secrets{Policy: SecretsConfirm, Chats: map[int64]SecretsPolicy{1: "ask"}}
+	if err := s.init(); err == nil {
+		t.Error("expected error")
+	}
+
+	var empty Secrets
+	if policy := empty.ChatPolicy(1); policy != SecretsMask {
+		t.Errorf("unexpected default policy: %q", policy)
+	}
+}
+
+func TestChatTokens(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"tokens":[{"id":"1","text":"Кто"},{"id":"2","text":" ты"},{"id":"3","text":"?"}]}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	chat := &Chat{APIKey: "test-key", TokenizeURL: s.URL, Client: s.Client()}
+
+	n, err := chat.TokenCount(context.Background(), "Кто ты?")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 3 {
+		t.Errorf("unexpected tokens: %d", n)
+	}
+
+	if budget := chat.InputBudget(); budget != ygpt.DefaultContextTokens-ygpt.DefaultMaxTokens {
+		t.Errorf("unexpected default budget: %d", budget)
+	}
+
+	chat.ContextTokens, chat.MaxTokens = 4000, 1000
+	if budget := chat.InputBudget(); budget != 3000 {
+		t.Errorf("unexpected budget: %d", budget)
+	}
+}
+
+func TestChatTokensModeration(t *testing.T) {
+	var texts []string
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text string `json:"text"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		texts = append(texts, body.Text)
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := fmt.Fprint(w, `{"tokens":[{"id":"1","text":"mail"}]}`); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	filter, err := moderation.New(&moderation.Options{DenyWords: []string{"secret"}, PII: moderation.ActionRedact})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chat := &Chat{APIKey: "test-key", TokenizeURL: s.URL, Client: s.Client(), Moderation: Moderation{Filter: filter}}
+
+	if _, err = chat.TokenCount(context.Background(), "mail user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = chat.TokenCount(context.Background(), "a secret plan"); !errors.Is(err, moderation.ErrDenied) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if len(texts) != 1 || texts[0] != "mail [EMAIL]" {
+		t.Errorf("unexpected tokenized texts: %q", texts)
 	}
 }
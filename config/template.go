@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// inputPlaceholder is a template placeholder of the user's input.
+const inputPlaceholder = "{{.Input}}"
+
+// templateName is a valid prompt template name.
+var templateName = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// TemplateData is a data to render prompt templates.
+type TemplateData struct {
+	Input string
+}
+
+// ParseTemplate returns a parsed prompt template.
+// If the text has no input placeholder, it is appended to the end.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	if !templateName.MatchString(name) {
+		return nil, fmt.Errorf("invalid template name %q, it must match %v", name, templateName)
+	}
+
+	if !strings.Contains(text, ".Input") {
+		text = strings.TrimSpace(text) + "\n\n" + inputPlaceholder
+	}
+
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %q: %w", name, err)
+	}
+
+	return t, nil
+}
+
+// RenderTemplate returns a prompt text of the template for the input.
+func RenderTemplate(t *template.Template, input string) (string, error) {
+	var b strings.Builder
+
+	if err := t.Execute(&b, TemplateData{Input: input}); err != nil {
+		return "", fmt.Errorf("failed to render template %q: %w", t.Name(), err)
+	}
+
+	return strings.TrimSpace(b.String()), nil
+}
+
+// initTemplates validates prompt templates.
+func (c *Config) initTemplates() error {
+	for name, text := range c.Templates {
+		if _, err := ParseTemplate(name, text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+func TestParseTemplate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tmpl     string
+		input    string
+		expected string
+		err      bool
+	}{
+		{name: "review", tmpl: "Review this Go code:\n{{.Input}}", input: "x := 1", expected: "Review this Go code:\nx := 1"},
+		{name: "no-input", tmpl: "Translate to English", input: "привет", expected: "Translate to English\n\nпривет"},
+		{name: "Bad", tmpl: "{{.Input}}", err: true},
+		{name: "bad", tmpl: "{{.Input", err: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tc.name, tc.tmpl)
+			if err != nil {
+				if !tc.err {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if tc.err {
+				t.Fatal("expected error")
+			}
+
+			text, err := RenderTemplate(tmpl, tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if text != tc.expected {
+				t.Errorf("expected: %q, got: %q", tc.expected, text)
+			}
+		})
+	}
+}
+
+func TestInitTemplates(t *testing.T) {
+	c := &Config{Templates: map[string]string{"review": "Review:\n{{.Input}}"}}
+	if err := c.initTemplates(); err != nil {
+		t.Error(err)
+	}
+
+	c.Templates["bad"] = "{{.Unknown"
+	if err := c.initTemplates(); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,217 @@
+package document
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Extensions are supported document file extensions.
+var Extensions = []string{".txt", ".md", ".go", ".json", ".csv", ".pdf"}
+
+var (
+	// ErrUnsupported is an error that occurs when a document type is not supported.
+	ErrUnsupported = errors.New("unsupported document type")
+
+	// ErrExtract is an error that occurs when a text can not be extracted from a document.
+	ErrExtract = errors.New("failed to extract text")
+)
+
+// Document is an uploaded document split into chunks.
+type Document struct {
+	Name   string
+	Chunks []string
+	Length int // number of characters
+}
+
+// Supported returns true if the document type is supported by its file name.
+func Supported(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+
+	for _, e := range Extensions {
+		if e == ext {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Extract returns a text of the document data.
+func Extract(name string, data []byte) (string, error) {
+	var (
+		text string
+		err  error
+	)
+
+	switch ext := strings.ToLower(filepath.Ext(name)); {
+	case !Supported(name):
+		return "", errors.Join(ErrUnsupported, fmt.Errorf("extension %q", ext))
+	case ext == ".pdf":
+		text, err = extractPDF(data)
+	default:
+		text, err = extractText(data)
+	}
+
+	if err != nil {
+		return "", errors.Join(ErrExtract, err)
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.Join(ErrExtract, fmt.Errorf("document %q has no text", name))
+	}
+
+	return text, nil
+}
+
+// extractText returns a text of a plain text document.
+func extractText(data []byte) (string, error) {
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("not a UTF-8 text")
+	}
+
+	return string(data), nil
+}
+
+// extractPDF returns a text layer of a PDF document.
+func extractPDF(data []byte) (text string, err error) {
+	defer func() {
+		// PDF reader panics on malformed documents
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	plain, err := reader.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("failed to get PDF text: %w", err)
+	}
+
+	b, err := io.ReadAll(plain)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF text: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// New returns a new document with the text split into chunks
+// of no more than chunkSize characters, lines are not split if possible.
+func New(name, text string, chunkSize int) *Document {
+	var (
+		chunks []string
+		b      strings.Builder
+		n      int
+	)
+
+	flush := func() {
+		if s := strings.TrimSpace(b.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		b.Reset()
+		n = 0
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		runes := []rune(line)
+
+		// too long line is split by characters
+		for i := 0; i < len(runes); i += chunkSize {
+			part := runes[i:min(i+chunkSize, len(runes))]
+			if n+len(part) > chunkSize {
+				flush()
+			}
+
+			b.WriteString(string(part))
+			n += len(part)
+		}
+	}
+	flush()
+
+	return &Document{Name: name, Chunks: chunks, Length: utf8.RuneCountInString(text)}
+}
+
+// Context returns document chunks which are the most relevant to the query,
+// their total length is no more than limit characters.
+// Chunks are kept in the document order.
+func (d *Document) Context(query string, limit int) string {
+	type scored struct {
+		index int
+		score int
+	}
+
+	var (
+		words  = wordSet(query)
+		scores = make([]scored, len(d.Chunks))
+	)
+
+	for i, chunk := range d.Chunks {
+		scores[i] = scored{index: i}
+
+		for w := range wordSet(chunk) {
+			if _, ok := words[w]; ok {
+				scores[i].score++
+			}
+		}
+	}
+
+	// the most relevant chunks first, the document beginning is preferred for equal scores
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].score > scores[j].score
+	})
+
+	var (
+		selected = make([]int, 0, len(scores))
+		total    int
+	)
+
+	for _, s := range scores {
+		size := utf8.RuneCountInString(d.Chunks[s.index])
+		if total+size > limit {
+			continue
+		}
+
+		selected = append(selected, s.index)
+		total += size
+	}
+
+	sort.Ints(selected)
+	parts := make([]string, len(selected))
+
+	for i, index := range selected {
+		parts[i] = d.Chunks[index]
+	}
+
+	return strings.Join(parts, "\n...\n")
+}
+
+// wordSet returns a set of lower-cased words of the text.
+func wordSet(text string) map[string]struct{} {
+	words := make(map[string]struct{})
+
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for _, w := range fields {
+		if utf8.RuneCountInString(w) > 2 {
+			words[w] = struct{}{}
+		}
+	}
+
+	return words
+}
@@ -0,0 +1,157 @@
+package document
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// minimalPDF returns a one page PDF document with the text.
+func minimalPDF(text string) []byte {
+	content := fmt.Sprintf("BT /F1 12 Tf 72 712 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] " +
+			"/Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var (
+		b       strings.Builder
+		offsets = make([]int, len(objects))
+	)
+
+	b.WriteString("%PDF-1.4\n")
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return []byte(b.String())
+}
+
+func TestSupported(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "test.txt", expected: true},
+		{name: "README.MD", expected: true},
+		{name: "main.go", expected: true},
+		{name: "data.json", expected: true},
+		{name: "table.csv", expected: true},
+		{name: "doc.pdf", expected: true},
+		{name: "image.png"},
+		{name: "noext"},
+	}
+
+	for _, tc := range testCases {
+		if s := Supported(tc.name); s != tc.expected {
+			t.Errorf("unexpected result for %q: %v", tc.name, s)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected string
+		err      error
+	}{
+		{name: "test.txt", data: []byte(" hello\n"), expected: "hello"},
+		{name: "doc.pdf", data: minimalPDF("Hello PDF"), expected: "Hello PDF"},
+		{name: "image.png", data: []byte("png"), err: ErrUnsupported},
+		{name: "empty.md", data: []byte("\n\t"), err: ErrExtract},
+		{name: "binary.csv", data: []byte{0xff, 0xfe, 0xfd}, err: ErrExtract},
+		{name: "bad.pdf", data: []byte("%PDF-1.4 bad"), err: ErrExtract},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			text, err := Extract(tc.name, tc.data)
+			if err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected error: %v, got: %v", tc.err, err)
+				}
+				return
+			}
+
+			if tc.err != nil {
+				t.Fatalf("expected error, but got nil")
+			}
+
+			if text != tc.expected {
+				t.Errorf("expected: %q, got: %q", tc.expected, text)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	text := "first line\nsecond line\n" + strings.Repeat("ж", 25) + "\nlast"
+	d := New("test.txt", text, 12)
+
+	expected := []string{"first line", "second line", "жжжжжжжжжжжж", "жжжжжжжжжжжж", "ж\nlast"}
+	if len(d.Chunks) != len(expected) {
+		t.Fatalf("unexpected chunks: %q", d.Chunks)
+	}
+
+	for i, chunk := range d.Chunks {
+		if chunk != expected[i] {
+			t.Errorf("unexpected chunk %d: %q", i, chunk)
+		}
+	}
+
+	if d.Length != 53 {
+		t.Errorf("unexpected length: %d", d.Length)
+	}
+}
+
+func TestNewLongLine(t *testing.T) {
+	d := New("test.txt", strings.Repeat("ж", 1<<20), 1000)
+
+	if n := len(d.Chunks); n != 1049 {
+		t.Fatalf("unexpected chunks number: %d", n)
+	}
+
+	if n := utf8.RuneCountInString(d.Chunks[0]); n != 1000 {
+		t.Errorf("unexpected first chunk length: %d", n)
+	}
+
+	if n := utf8.RuneCountInString(d.Chunks[len(d.Chunks)-1]); n != 576 {
+		t.Errorf("unexpected last chunk length: %d", n)
+	}
+}
+
+func TestDocument_Context(t *testing.T) {
+	d := &Document{
+		Name:   "test.txt",
+		Chunks: []string{"about cats", "about dogs", "dogs and cats", "about birds"},
+	}
+
+	if c := d.Context("what about dogs?", 100); c != "about cats\n...\nabout dogs\n...\ndogs and cats\n...\nabout birds" {
+		t.Errorf("unexpected full context: %q", c)
+	}
+
+	if c := d.Context("dogs only", 25); c != "about dogs\n...\ndogs and cats" {
+		t.Errorf("unexpected context: %q", c)
+	}
+
+	if c := d.Context("birds", 11); c != "about birds" {
+		t.Errorf("unexpected context: %q", c)
+	}
+}
@@ -0,0 +1,343 @@
+package i18n
+
+// Key identifies a localized message.
+type Key string
+
+// Generation messages.
+const (
+	Generating           Key = "generating"
+	Cancelled            Key = "cancelled"
+	StopButton           Key = "stop_button"
+	Stopped              Key = "stopped"
+	AlreadyFinished      Key = "already_finished"
+	NoGenerations        Key = "no_generations"
+	CancelledGenerations Key = "cancelled_generations"
+	CompletionFailed     Key = "completion_failed"
+	InputTooLarge        Key = "input_too_large"
+	HandlerFailed        Key = "handler_failed"
+	Failed               Key = "failed"
+)
+
+// Error explanations for users.
+const (
+	ErrorReference   Key = "error_reference"
+	ErrorTimeout     Key = "error_timeout"
+	ErrorRateLimit   Key = "error_rate_limit"
+	ErrorFiltered    Key = "error_filtered"
+	ErrorBlocked     Key = "error_blocked"
+	ErrorRefused     Key = "error_refused"
+	ErrorQuota       Key = "error_quota"
+	ErrorUnavailable Key = "error_unavailable"
+	ErrorUnknown     Key = "error_unknown"
+)
+
+// Instructions of the chat generation.
+const (
+	DefaultInstruction  Key = "default_instruction"
+	DocumentInstruction Key = "document_instruction"
+	PhotoInstruction    Key = "photo_instruction"
+	PageInstruction     Key = "page_instruction"
+	ForwardInstruction  Key = "forward_instruction"
+	MemoryInstruction   Key = "memory_instruction"
+	MemoryContext       Key = "memory_context"
+)
+
+// Command messages.
+const (
+	DocumentUnsupported Key = "document_unsupported"
+	DocumentTooLarge    Key = "document_too_large"
+	DocumentFailed      Key = "document_failed"
+	DocumentLoaded      Key = "document_loaded"
+	DocumentForgotten   Key = "document_forgotten"
+	DocumentUsage       Key = "document_usage"
+	DocumentNone        Key = "document_none"
+	DocumentInfo        Key = "document_info"
+
+	ExportUsage     Key = "export_usage"
+	ExportFailed    Key = "export_failed"
+	HistoryEmpty    Key = "history_empty"
+	HistoryDisabled Key = "history_disabled"
+	ImportUsage     Key = "import_usage"
+	ImportTooLarge  Key = "import_too_large"
+	ImportFailed    Key = "import_failed"
+	Imported        Key = "imported"
+	Reset           Key = "reset"
+	MemoryUsage     Key = "memory_usage"
+	MemoryNone      Key = "memory_none"
+	MemoryInfo      Key = "memory_info"
+	MemoryForgotten Key = "memory_forgotten"
+
+	PhotoTooLarge Key = "photo_too_large"
+	PhotoFailed   Key = "photo_failed"
+	PhotoNoText   Key = "photo_no_text"
+
+	SummarizeUsage Key = "summarize_usage"
+	PageFailed     Key = "page_failed"
+	PageEmpty      Key = "page_empty"
+
+	TemplateUsage       Key = "template_usage"
+	TemplateSaved       Key = "template_saved"
+	TemplateDeleted     Key = "template_deleted"
+	TemplateNotOwned    Key = "template_not_owned"
+	TemplateNotFound    Key = "template_not_found"
+	TemplatePromptUsage Key = "template_prompt_usage"
+	TemplateEmptyInput  Key = "template_empty_input"
+	TemplatesCommon     Key = "templates_common"
+	TemplatesUser       Key = "templates_user"
+	TemplatesNone       Key = "templates_none"
+
+	VoiceTooLong       Key = "voice_too_long"
+	VoiceTooLarge      Key = "voice_too_large"
+	VoiceFailed        Key = "voice_failed"
+	VoiceNotRecognized Key = "voice_not_recognized"
+	VoiceRecognized    Key = "voice_recognized"
+	SynthesisFailed    Key = "synthesis_failed"
+	SayUsage           Key = "say_usage"
+	VoiceUsage         Key = "voice_usage"
+	VoiceOn            Key = "voice_on"
+	VoiceOff           Key = "voice_off"
+
+	LangUsage   Key = "lang_usage"
+	LangCurrent Key = "lang_current"
+
+	SecretsFound        Key = "secrets_found"
+	SecretsSendButton   Key = "secrets_send_button"
+	SecretsMaskButton   Key = "secrets_mask_button"
+	SecretsCancelButton Key = "secrets_cancel_button"
+	SecretsExpired      Key = "secrets_expired"
+)
+
+// Admin command messages.
+const (
+	MaintenanceNotice     Key = "maintenance_notice"
+	MaintenanceUsage      Key = "maintenance_usage"
+	MaintenanceMode       Key = "maintenance_mode"
+	StatsUptime           Key = "stats_uptime"
+	StatsVersion          Key = "stats_version"
+	StatsRequests         Key = "stats_requests"
+	StatsTokens           Key = "stats_tokens"
+	StatsCache            Key = "stats_cache"
+	BroadcastUsage        Key = "broadcast_usage"
+	BroadcastSent         Key = "broadcast_sent"
+	LogLevel              Key = "log_level"
+	LogLevelUsage         Key = "log_level_usage"
+	LogLevelNotConfigured Key = "log_level_not_configured"
+)
+
+// catalog contains messages of all supported languages.
+var catalog = map[Lang]map[Key]string{
+	English: {
+		Generating:           "Generating an answer...",
+		Cancelled:            "The generation is cancelled.",
+		StopButton:           "Stop",
+		Stopped:              "Stopped",
+		AlreadyFinished:      "The generation is already finished",
+		NoGenerations:        "There are no generations in progress.",
+		CancelledGenerations: "Cancelled generations: %d.",
+		CompletionFailed:     "ERROR: failed to get completion: %v",
+		InputTooLarge:        "The message is too long: about %d tokens with the context, the limit is %d. Shorten it or split into parts.",
+		HandlerFailed:        "oops, an error has occurred\n\n%v",
+		Failed:               "ERROR: %v",
+
+		ErrorReference:   "%s (error ID: %s)",
+		ErrorTimeout:     "the request took too long, try again or shorten it",
+		ErrorRateLimit:   "too many requests, wait a minute and try again",
+		ErrorFiltered:    "the request is rejected by the content filter, rephrase it",
+		ErrorBlocked:     "the request contains denied content or personal data and is not sent",
+		ErrorRefused:     "the model refused to answer this request, rephrase it",
+		ErrorQuota:       "the usage quota is exceeded, contact the bot administrator",
+		ErrorUnavailable: "the service is temporarily unavailable, try again later",
+		ErrorUnknown:     "an unexpected error has occurred, try again later",
+
+		DefaultInstruction:  "You are a helpful assistant. Answer in English unless the user asks otherwise.",
+		DocumentInstruction: "Answer the user's questions using the document %q. Document fragments:\n\n%s",
+		PhotoInstruction:    "Explain the text recognized from the image.",
+		PageInstruction:     "Summarize the web page text briefly: the main topic, key facts and conclusions.",
+		ForwardInstruction:  "Summarize the forwarded conversation briefly: the main points, decisions and open questions.",
+		MemoryInstruction:   "Update the summary of the earlier conversation with the new turns. Keep facts, names, decisions, user preferences and open questions needed to continue the conversation. Answer only with the summary of no more than 150 words.",
+		MemoryContext:       "Summary of our earlier conversation:\n%s",
+
+		DocumentUnsupported: "ERROR: unsupported document type %q, supported: %s",
+		DocumentTooLarge:    "ERROR: document is too large, maximum size is %d bytes",
+		DocumentFailed:      "ERROR: failed to load document: %v",
+		DocumentLoaded:      "Document %q is loaded (%d characters), ask questions about it. Use /document clear to forget it.",
+		DocumentForgotten:   "Document is forgotten.",
+		DocumentUsage:       "Usage: /document [clear]",
+		DocumentNone:        "There is no document in the chat, upload one to ask questions about it.",
+		DocumentInfo:        "Document %q, %d characters, %d chunks.",
+
+		ExportUsage:     "Usage: /export [md|json]",
+		ExportFailed:    "ERROR: failed to export conversation: %v",
+		HistoryEmpty:    "The conversation history is empty.",
+		HistoryDisabled: "The conversation history is disabled.",
+		ImportUsage:     "Usage: reply /import to a JSON export or send it with /import caption",
+		ImportTooLarge:  "ERROR: export is too large, maximum size is %d bytes",
+		ImportFailed:    "ERROR: failed to import conversation: %v",
+		Imported:        "Conversation is imported, turns: %d.",
+		Reset:           "Conversation is forgotten, turns: %d.",
+		MemoryUsage:     "Usage: /memory [clear]",
+		MemoryNone:      "There is no memory of the earlier conversation yet.",
+		MemoryInfo:      "Memory of %d earlier turns, updated %s:\n\n%s",
+		MemoryForgotten: "Memory is forgotten.",
+
+		PhotoTooLarge: "ERROR: photo is too large",
+		PhotoFailed:   "ERROR: failed to recognize text on photo: %v",
+		PhotoNoText:   "Text is not found on the photo.",
+
+		SummarizeUsage: "Usage: /summarize <url> or reply /summarize to a message with URL.",
+		PageFailed:     "ERROR: failed to get page: %v",
+		PageEmpty:      "The page has no readable text.",
+
+		TemplateUsage:       "Usage:\n/template list\n/template add <name> <text with {{.Input}}>\n/template del <name>",
+		TemplateSaved:       "Template %q is saved, use it by /t %s <text>.",
+		TemplateDeleted:     "Template %q is deleted.",
+		TemplateNotOwned:    "Your template %q is not found.",
+		TemplateNotFound:    "Template %q is not found, see /template list.",
+		TemplatePromptUsage: "Usage: /t <template> <text> or reply /t <template> to a message.",
+		TemplateEmptyInput:  "ERROR: empty input, add a text after the template name or reply to a message.",
+		TemplatesCommon:     "Common templates",
+		TemplatesUser:       "Your templates",
+		TemplatesNone:       "There are no templates, add one by /template add <name> <text>.",
+
+		VoiceTooLong:       "ERROR: voice message is too long, maximum duration is %v",
+		VoiceTooLarge:      "ERROR: voice message is too large",
+		VoiceFailed:        "ERROR: failed to recognize voice message: %v",
+		VoiceNotRecognized: "Speech is not recognized.",
+		VoiceRecognized:    "Recognized: %s",
+		SynthesisFailed:    "ERROR: failed to synthesize speech: %v",
+		SayUsage:           "Usage: /say <text> or reply /say to a message.",
+		VoiceUsage:         "Usage: /voice on|off",
+		VoiceOn:            "Voice replies are on.",
+		VoiceOff:           "Voice replies are off.",
+
+		LangUsage:   "Usage: /lang [en|ru|auto]",
+		LangCurrent: "Language: %s.",
+
+		SecretsFound:        "The message contains possible secrets: %s. Send it as is, mask them or cancel?",
+		SecretsSendButton:   "Send as is",
+		SecretsMaskButton:   "Mask and send",
+		SecretsCancelButton: "Cancel",
+		SecretsExpired:      "The confirmation is expired or belongs to another user",
+
+		MaintenanceNotice:     "The bot is under maintenance, please try again later.",
+		MaintenanceUsage:      "Usage: /maintenance [on|off]",
+		MaintenanceMode:       "Maintenance: %s",
+		StatsUptime:           "Uptime: %s",
+		StatsVersion:          "Version: %s, revision: %s",
+		StatsRequests:         "Requests: %d, errors: %d (%.1f%%)",
+		StatsTokens:           "Tokens: %d, today: %d",
+		StatsCache:            "Cache: size %d, hits %d, misses %d",
+		BroadcastUsage:        "Usage: /broadcast <text>",
+		BroadcastSent:         "Broadcast is sent: %d, failed: %d.",
+		LogLevel:              "Log level: %s",
+		LogLevelUsage:         "Usage: /loglevel [debug|info|warn|error]",
+		LogLevelNotConfigured: "ERROR: logging level is not configured",
+	},
+	Russian: {
+		Generating:           "Генерирую ответ...",
+		Cancelled:            "Генерация отменена.",
+		StopButton:           "Стоп",
+		Stopped:              "Остановлено",
+		AlreadyFinished:      "Генерация уже завершена",
+		NoGenerations:        "Нет генераций в процессе.",
+		CancelledGenerations: "Отменено генераций: %d.",
+		CompletionFailed:     "ОШИБКА: не удалось получить ответ: %v",
+		InputTooLarge:        "Сообщение слишком длинное: около %d токенов с контекстом, ограничение %d. Сократите его или разделите на части.",
+		HandlerFailed:        "упс, произошла ошибка\n\n%v",
+		Failed:               "ОШИБКА: %v",
+
+		ErrorReference:   "%s (код ошибки: %s)",
+		ErrorTimeout:     "запрос выполнялся слишком долго, повторите его или сократите",
+		ErrorRateLimit:   "слишком много запросов, подождите минуту и повторите",
+		ErrorFiltered:    "запрос отклонён фильтром содержимого, переформулируйте его",
+		ErrorBlocked:     "запрос содержит запрещённое содержимое или персональные данные и не отправлен",
+		ErrorRefused:     "модель отказалась отвечать на этот запрос, переформулируйте его",
+		ErrorQuota:       "превышена квота использования, обратитесь к администратору бота",
+		ErrorUnavailable: "сервис временно недоступен, попробуйте позже",
+		ErrorUnknown:     "произошла непредвиденная ошибка, попробуйте позже",
+
+		DefaultInstruction:  "Ты полезный ассистент. Отвечай на русском языке, если пользователь не просит иначе.",
+		DocumentInstruction: "Отвечай на вопросы пользователя, используя документ %q. Фрагменты документа:\n\n%s",
+		PhotoInstruction:    "Объясни текст, распознанный на изображении.",
+		PageInstruction:     "Кратко перескажи текст веб-страницы: основную тему, ключевые факты и выводы.",
+		ForwardInstruction:  "Кратко перескажи пересланную переписку: основные моменты, решения и открытые вопросы.",
+		MemoryInstruction:   "Дополни краткое содержание предыдущего разговора новыми репликами. Сохрани факты, имена, решения, предпочтения пользователя и открытые вопросы, нужные для продолжения разговора. Ответь только кратким содержанием не длиннее 150 слов.",
+		MemoryContext:       "Краткое содержание нашего предыдущего разговора:\n%s",
+
+		DocumentUnsupported: "ОШИБКА: неподдерживаемый тип документа %q, поддерживаются: %s",
+		DocumentTooLarge:    "ОШИБКА: документ слишком большой, максимальный размер %d байт",
+		DocumentFailed:      "ОШИБКА: не удалось загрузить документ: %v",
+		DocumentLoaded:      "Документ %q загружен (символов: %d), задавайте вопросы по нему. Чтобы забыть его, используйте /document clear.",
+		DocumentForgotten:   "Документ забыт.",
+		DocumentUsage:       "Использование: /document [clear]",
+		DocumentNone:        "В чате нет документа, загрузите его, чтобы задавать по нему вопросы.",
+		DocumentInfo:        "Документ %q, символов: %d, фрагментов: %d.",
+
+		ExportUsage:     "Использование: /export [md|json]",
+		ExportFailed:    "ОШИБКА: не удалось экспортировать разговор: %v",
+		HistoryEmpty:    "История разговора пуста.",
+		HistoryDisabled: "История разговора отключена.",
+		ImportUsage:     "Использование: ответьте /import на JSON-экспорт или отправьте его с подписью /import",
+		ImportTooLarge:  "ОШИБКА: экспорт слишком большой, максимальный размер %d байт",
+		ImportFailed:    "ОШИБКА: не удалось импортировать разговор: %v",
+		Imported:        "Разговор импортирован, реплик: %d.",
+		Reset:           "Разговор забыт, реплик: %d.",
+		MemoryUsage:     "Использование: /memory [clear]",
+		MemoryNone:      "Памяти о предыдущем разговоре пока нет.",
+		MemoryInfo:      "Память о %d предыдущих репликах, обновлена %s:\n\n%s",
+		MemoryForgotten: "Память забыта.",
+
+		PhotoTooLarge: "ОШИБКА: фото слишком большое",
+		PhotoFailed:   "ОШИБКА: не удалось распознать текст на фото: %v",
+		PhotoNoText:   "Текст на фото не найден.",
+
+		SummarizeUsage: "Использование: /summarize <url> или ответьте /summarize на сообщение со ссылкой.",
+		PageFailed:     "ОШИБКА: не удалось получить страницу: %v",
+		PageEmpty:      "На странице нет читаемого текста.",
+
+		TemplateUsage:       "Использование:\n/template list\n/template add <имя> <текст с {{.Input}}>\n/template del <имя>",
+		TemplateSaved:       "Шаблон %q сохранён, используйте его так: /t %s <текст>.",
+		TemplateDeleted:     "Шаблон %q удалён.",
+		TemplateNotOwned:    "Ваш шаблон %q не найден.",
+		TemplateNotFound:    "Шаблон %q не найден, см. /template list.",
+		TemplatePromptUsage: "Использование: /t <шаблон> <текст> или ответьте /t <шаблон> на сообщение.",
+		TemplateEmptyInput:  "ОШИБКА: пустой ввод, добавьте текст после имени шаблона или ответьте на сообщение.",
+		TemplatesCommon:     "Общие шаблоны",
+		TemplatesUser:       "Ваши шаблоны",
+		TemplatesNone:       "Шаблонов нет, добавьте шаблон командой /template add <имя> <текст>.",
+
+		VoiceTooLong:       "ОШИБКА: голосовое сообщение слишком длинное, максимальная длительность %v",
+		VoiceTooLarge:      "ОШИБКА: голосовое сообщение слишком большое",
+		VoiceFailed:        "ОШИБКА: не удалось распознать голосовое сообщение: %v",
+		VoiceNotRecognized: "Речь не распознана.",
+		VoiceRecognized:    "Распознано: %s",
+		SynthesisFailed:    "ОШИБКА: не удалось синтезировать речь: %v",
+		SayUsage:           "Использование: /say <текст> или ответьте /say на сообщение.",
+		VoiceUsage:         "Использование: /voice on|off",
+		VoiceOn:            "Голосовые ответы включены.",
+		VoiceOff:           "Голосовые ответы выключены.",
+
+		LangUsage:   "Использование: /lang [en|ru|auto]",
+		LangCurrent: "Язык: %s.",
+
+		SecretsFound:        "Сообщение может содержать секреты: %s. Отправить как есть, скрыть их или отменить?",
+		SecretsSendButton:   "Отправить как есть",
+		SecretsMaskButton:   "Скрыть и отправить",
+		SecretsCancelButton: "Отменить",
+		SecretsExpired:      "Подтверждение устарело или принадлежит другому пользователю",
+
+		MaintenanceNotice:     "Бот на техническом обслуживании, попробуйте позже.",
+		MaintenanceUsage:      "Использование: /maintenance [on|off]",
+		MaintenanceMode:       "Техническое обслуживание: %s",
+		StatsUptime:           "Время работы: %s",
+		StatsVersion:          "Версия: %s, ревизия: %s",
+		StatsRequests:         "Запросов: %d, ошибок: %d (%.1f%%)",
+		StatsTokens:           "Токенов: %d, сегодня: %d",
+		StatsCache:            "Кэш: размер %d, попаданий %d, промахов %d",
+		BroadcastUsage:        "Использование: /broadcast <текст>",
+		BroadcastSent:         "Рассылка отправлена: %d, ошибок: %d.",
+		LogLevel:              "Уровень логирования: %s",
+		LogLevelUsage:         "Использование: /loglevel [debug|info|warn|error]",
+		LogLevelNotConfigured: "ОШИБКА: уровень логирования не настроен",
+	},
+}
@@ -0,0 +1,51 @@
+// Package i18n provides localized bot messages.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lang is a supported language code.
+type Lang string
+
+// Supported languages.
+const (
+	English Lang = "en"
+	Russian Lang = "ru"
+
+	// Default is used if the user's language is unknown or not supported.
+	Default = English
+)
+
+// Languages are supported languages.
+var Languages = []Lang{English, Russian}
+
+// Parse returns a supported language of the IETF language tag, for example "ru" or "en-US".
+func Parse(code string) (Lang, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(code)), "-")
+
+	for _, lang := range Languages {
+		if string(lang) == base {
+			return lang, true
+		}
+	}
+
+	return "", false
+}
+
+// T returns the message formatted with arguments, the default language is used if the message is not translated.
+func (l Lang) T(key Key, args ...any) string {
+	text, ok := catalog[l][key]
+	if !ok {
+		if text, ok = catalog[Default][key]; !ok {
+			return string(key)
+		}
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+
+	return fmt.Sprintf(text, args...)
+}
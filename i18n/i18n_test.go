@@ -0,0 +1,79 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// verbRegexp matches formatting verbs of messages.
+var verbRegexp = regexp.MustCompile(`%[-+# 0]*[0-9.]*[a-zA-Z]`)
+
+func TestCatalog(t *testing.T) {
+	for _, lang := range Languages {
+		messages, ok := catalog[lang]
+		if !ok {
+			t.Fatalf("no messages of %q", lang)
+		}
+
+		for key, text := range catalog[Default] {
+			translated, found := messages[key]
+			if !found {
+				t.Errorf("%q message %q is not translated", lang, key)
+				continue
+			}
+
+			if expected, verbs := verbRegexp.FindAllString(text, -1), verbRegexp.FindAllString(translated, -1); !slices.Equal(expected, verbs) {
+				t.Errorf("%q message %q has verbs %v, expected %v", lang, key, verbs, expected)
+			}
+		}
+
+		for key := range messages {
+			if _, found := catalog[Default][key]; !found {
+				t.Errorf("%q message %q is unknown", lang, key)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		code string
+		lang Lang
+		ok   bool
+	}{
+		{code: "ru", lang: Russian, ok: true},
+		{code: "en-US", lang: English, ok: true},
+		{code: " RU ", lang: Russian, ok: true},
+		{code: "de", ok: false},
+		{code: "", ok: false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.code, func(t *testing.T) {
+			lang, ok := Parse(tc.code)
+			if lang != tc.lang || ok != tc.ok {
+				t.Errorf("unexpected result: %q, %v", lang, ok)
+			}
+		})
+	}
+}
+
+func TestLangT(t *testing.T) {
+	if text := Russian.T(CancelledGenerations, 2); text != "Отменено генераций: 2." {
+		t.Errorf("unexpected text: %q", text)
+	}
+
+	if text := English.T(Generating); text != "Generating an answer..." {
+		t.Errorf("unexpected text: %q", text)
+	}
+
+	if text := Lang("de").T(Stopped); text != "Stopped" {
+		t.Errorf("unexpected fallback text: %q", text)
+	}
+
+	if text := English.T(Key("unknown")); text != "unknown" {
+		t.Errorf("unexpected unknown text: %q", text)
+	}
+}
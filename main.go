@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log/slog"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/z0rr0/tgtpgybot/bot"
 	"github.com/z0rr0/tgtpgybot/config"
+	"github.com/z0rr0/tgtpgybot/tracing"
 )
 
 // Name is a bot name.
@@ -50,6 +52,7 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	cfg.Version, cfg.Revision = Version, Revision
 
 	slog.Info(
 		"main", "logging", cfg.DebugLevel,
@@ -57,6 +60,20 @@ func main() {
 	)
 	slog.Info("read config", "config", cfg)
 
+	if cfg.Tracing.Endpoint != "" {
+		provider, e := tracing.New(context.Background(), cfg.Tracing.Endpoint, cfg.Tracing.ServiceName)
+		if e != nil {
+			panic(e)
+		}
+
+		defer func() {
+			if e = provider.Shutdown(context.Background()); e != nil {
+				slog.Error("failed to shutdown tracing", "error", e)
+			}
+		}()
+		slog.Info("tracing", "endpoint", cfg.Tracing.Endpoint, "service_name", cfg.Tracing.ServiceName)
+	}
+
 	b, err := bot.New(cfg)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,129 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Personal data types.
+const (
+	TypeCard   = "card"
+	TypeAPIKey = "api_key"
+	TypeEmail  = "email"
+	TypePhone  = "phone"
+)
+
+// detector finds a personal data type in texts.
+type detector struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(string) bool // optional check of found values
+}
+
+// placeholder returns a replacement of the redacted value.
+func (d *detector) placeholder() string {
+	return "[" + strings.ToUpper(d.name) + "]"
+}
+
+// detectors are all personal data detectors, the order matters:
+// long digit sequences of cards are checked before phones and keys before emails.
+var detectors = []detector{
+	{
+		name:  TypeCard,
+		re:    regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		valid: validCard,
+	},
+	{
+		name:  TypeAPIKey,
+		re:    regexp.MustCompile(`\b[A-Za-z0-9_\-]{32,}`),
+		valid: validAPIKey,
+	},
+	{
+		name: TypeEmail,
+		re:   regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	},
+	{
+		name: TypePhone,
+		re:   regexp.MustCompile(`(?:\+\d{1,3}|\b8)[ \-]?\(?\d{3}\)?[ \-]?\d{3}[ \-]?(?:\d{2}[ \-]?\d{2}|\d{4})\b`),
+	},
+}
+
+// selectDetectors returns detectors of the types, all of them if the types are empty.
+func selectDetectors(types []string) ([]detector, error) {
+	if len(types) == 0 {
+		return detectors, nil
+	}
+
+	known := make(map[string]bool, len(types))
+	for _, t := range types {
+		known[t] = true
+	}
+
+	result := make([]detector, 0, len(types))
+	for _, d := range detectors {
+		if known[d.name] {
+			result = append(result, d)
+			delete(known, d.name)
+		}
+	}
+
+	for t := range known {
+		return nil, errors.Join(ErrOptions, fmt.Errorf("unknown PII type %q", t))
+	}
+
+	return result, nil
+}
+
+// digits returns only digits of the text.
+func digits(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, text)
+}
+
+// validCard checks a payment card number length and its Luhn checksum.
+func validCard(text string) bool {
+	number := digits(text)
+	if n := len(number); n < 13 || n > 19 {
+		return false
+	}
+
+	var sum int
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if (len(number)-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+
+	return sum%10 == 0
+}
+
+// validAPIKey checks that a long token contains upper and lower case letters and digits,
+// so hex hashes and usual words are skipped.
+func validAPIKey(text string) bool {
+	var upper, lower, digit bool
+
+	for _, r := range text {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+
+	return upper && lower && digit
+}
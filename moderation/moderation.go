@@ -0,0 +1,160 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrDenied is an error that occurs when a prompt matches a deny list.
+	ErrDenied = errors.New("prompt contains denied content")
+
+	// ErrPersonalData is an error that occurs when a prompt contains blocked personal data.
+	ErrPersonalData = errors.New("prompt contains personal data")
+
+	// ErrRefusal is an error that occurs when an answer is a canned refusal of the model.
+	ErrRefusal = errors.New("model refused to answer")
+
+	// ErrOptions is an error that occurs when filter options are invalid.
+	ErrOptions = errors.New("invalid moderation options")
+)
+
+// Action is a way to handle personal data in prompts.
+type Action string
+
+// Supported personal data actions, personal data is not checked if the action is empty.
+const (
+	ActionRedact Action = "redact"
+	ActionBlock  Action = "block"
+)
+
+// Refusals are the beginnings of canned YandexGPT answers to the rejected requests,
+// they are compared with lowercase answers without extra spaces.
+var Refusals = []string{
+	"к сожалению, я не могу ответить на этот вопрос",
+	"я не могу ответить на этот вопрос",
+	"я не могу обсуждать эту тему",
+	"не могу обсуждать эту тему",
+	"в интернете есть много сайтов с информацией на эту тему",
+	"есть темы, в которых я могу ошибиться",
+	"давайте сменим тему",
+	"давайте поговорим о чём-нибудь ещё",
+}
+
+// Options is a filter configuration.
+type Options struct {
+	DenyWords    []string // case-insensitive denied words and phrases
+	DenyPatterns []string // denied regular expressions
+	PII          Action   // personal data action, it is not checked if empty
+	PIITypes     []string // checked personal data types, all are checked if empty
+	Refusals     []string // additional refusal answers beginnings
+}
+
+// Filter checks prompts before the generation and answers after it.
+type Filter struct {
+	denyWords    []string
+	denyPatterns []*regexp.Regexp
+	pii          Action
+	detectors    []detector
+	refusals     []string
+}
+
+// New creates a new filter, it always detects the known refusal answers.
+func New(opts *Options) (*Filter, error) {
+	f := &Filter{pii: opts.PII, refusals: make([]string, 0, len(Refusals)+len(opts.Refusals))}
+
+	switch opts.PII {
+	case "", ActionRedact, ActionBlock:
+	default:
+		return nil, errors.Join(ErrOptions, fmt.Errorf("unknown PII action %q", opts.PII))
+	}
+
+	for _, word := range opts.DenyWords {
+		if word = normalize(word); word != "" {
+			f.denyWords = append(f.denyWords, word)
+		}
+	}
+
+	for _, pattern := range opts.DenyPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Join(ErrOptions, fmt.Errorf("deny pattern %q: %w", pattern, err))
+		}
+		f.denyPatterns = append(f.denyPatterns, re)
+	}
+
+	if opts.PII != "" {
+		ds, err := selectDetectors(opts.PIITypes)
+		if err != nil {
+			return nil, err
+		}
+		f.detectors = ds
+	}
+
+	for _, refusals := range [][]string{Refusals, opts.Refusals} {
+		for _, refusal := range refusals {
+			if refusal = normalize(refusal); refusal != "" {
+				f.refusals = append(f.refusals, refusal)
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// Prompt checks the prompt text and returns it with redacted personal data.
+// ErrDenied or ErrPersonalData is returned if the prompt must not be sent.
+func (f *Filter) Prompt(text string) (string, error) {
+	normalized := normalize(text)
+
+	for _, word := range f.denyWords {
+		if strings.Contains(normalized, word) {
+			return "", errors.Join(ErrDenied, fmt.Errorf("word %q", word))
+		}
+	}
+
+	for _, re := range f.denyPatterns {
+		if re.MatchString(text) {
+			return "", errors.Join(ErrDenied, fmt.Errorf("pattern %q", re.String()))
+		}
+	}
+
+	for _, d := range f.detectors {
+		var found bool
+
+		text = d.re.ReplaceAllStringFunc(text, func(s string) string {
+			if d.valid != nil && !d.valid(s) {
+				return s
+			}
+			found = true
+			return d.placeholder()
+		})
+
+		if found && f.pii == ActionBlock {
+			return "", errors.Join(ErrPersonalData, fmt.Errorf("type %q", d.name))
+		}
+	}
+
+	return text, nil
+}
+
+// Answer returns ErrRefusal if the answer is a canned refusal.
+func (f *Filter) Answer(text string) error {
+	normalized := normalize(text)
+
+	for _, refusal := range f.refusals {
+		if strings.HasPrefix(normalized, refusal) {
+			return ErrRefusal
+		}
+	}
+
+	return nil
+}
+
+// normalize returns a lowercase text with single spaces.
+func normalize(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), unicode.IsSpace), " ")
+}
@@ -0,0 +1,136 @@
+package moderation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts Options
+		err  error
+	}{
+		{name: "empty"},
+		{name: "valid", opts: Options{DenyPatterns: []string{`(?i)secret\s+project`}, PII: ActionBlock, PIITypes: []string{TypeEmail}}},
+		{name: "action", opts: Options{PII: "hide"}, err: ErrOptions},
+		{name: "pattern", opts: Options{DenyPatterns: []string{`(`}}, err: ErrOptions},
+		{name: "type", opts: Options{PII: ActionRedact, PIITypes: []string{"passport"}}, err: ErrOptions},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := New(&tc.opts)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if f == nil {
+				t.Error("filter is nil")
+			}
+		})
+	}
+}
+
+func TestFilterPrompt(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     Options
+		text     string
+		expected string
+		err      error
+	}{
+		{name: "empty", text: "hello test@example.com", expected: "hello test@example.com"},
+		{name: "word", opts: Options{DenyWords: []string{"Secret  Project"}}, text: "about the SECRET\nproject", err: ErrDenied},
+		{name: "cyrillic", opts: Options{DenyWords: []string{"пароль"}}, text: "Мой ПАРОЛЬ", err: ErrDenied},
+		{name: "pattern", opts: Options{DenyPatterns: []string{`\bv\d+\.\d+-internal\b`}}, text: "release v1.2-internal", err: ErrDenied},
+		{name: "allowed", opts: Options{DenyWords: []string{"secret"}}, text: "hello", expected: "hello"},
+		{
+			name:     "redact",
+			opts:     Options{PII: ActionRedact},
+			text:     "mail test@example.com, call +7 (999) 123-45-67 or 89991234567, pay 4111 1111 1111 1111",
+			expected: "mail [EMAIL], call [PHONE] or [PHONE], pay [CARD]",
+		},
+		{
+			name:     "key",
+			opts:     Options{PII: ActionRedact},
+			text:     "key=AQVNx2Tb8kLmQ0aZ7fWcYp4RsJ3dHgE1uViO",
+			expected: "key=[API_KEY]",
+		},
+		{
+			name:     "skipped",
+			opts:     Options{PII: ActionRedact},
+			text:     "at 2024-01-15 10:30 commit 3f2a9c1e5b7d4f6a8c0e2b4d6f8a0c2e4b6d8f0a, id 1234 5678 9012 3456",
+			expected: "at 2024-01-15 10:30 commit 3f2a9c1e5b7d4f6a8c0e2b4d6f8a0c2e4b6d8f0a, id 1234 5678 9012 3456",
+		},
+		{
+			name:     "types",
+			opts:     Options{PII: ActionRedact, PIITypes: []string{TypePhone}},
+			text:     "test@example.com +7 999 123 45 67",
+			expected: "test@example.com [PHONE]",
+		},
+		{name: "block", opts: Options{PII: ActionBlock}, text: "my email is test@example.com", err: ErrPersonalData},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := New(&tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			text, err := f.Prompt(tc.text)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if text != tc.expected {
+				t.Errorf("unexpected text: %q", text)
+			}
+		})
+	}
+}
+
+func TestFilterAnswer(t *testing.T) {
+	f, err := New(&Options{Refusals: []string{"I can't help with that"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		text    string
+		refusal bool
+	}{
+		{name: "canned", text: "Я не могу обсуждать эту тему. Давайте поговорим о чём-нибудь ещё.", refusal: true},
+		{name: "search", text: "В интернете есть много сайтов с информацией на эту тему. [Посмотрите, что нашлось в поиске](https://ya.ru)", refusal: true},
+		{name: "custom", text: "  I CAN'T help   with that.", refusal: true},
+		{name: "answer", text: "Go — компилируемый язык. Я не могу обсуждать эту тему подробно без примеров."},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			err := f.Answer(tc.text)
+			if refusal := errors.Is(err, ErrRefusal); refusal != tc.refusal {
+				t.Errorf("unexpected result: %v", err)
+			}
+		})
+	}
+}
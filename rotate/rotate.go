@@ -0,0 +1,207 @@
+package rotate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is a time format of rotated file name suffixes, it is sortable.
+const backupTimeFormat = "20060102T150405.000000000"
+
+// ErrClosed is an error that occurs when a closed file is written.
+var ErrClosed = errors.New("file is closed")
+
+// File is an append-only file which is rotated by its size or age.
+// Rotated files are renamed to "<path>.<timestamp>", the oldest of them are removed.
+type File struct {
+	sync.Mutex
+	path       string
+	maxSize    int64         // rotate if the file is larger, it is not limited if it is not positive
+	maxAge     time.Duration // rotate if the file is older, it is not limited if it is not positive
+	maxBackups int           // maximum number of rotated files, all are kept if it is not positive
+	file       *os.File
+	size       int64
+	started    time.Time // the age of the file is counted from this time
+	closed     bool
+}
+
+// New opens or creates the file for appending.
+func New(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*File, error) {
+	f := &File{path: path, maxSize: maxSize, maxAge: maxAge, maxBackups: maxBackups}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// open opens the file for appending. The age of a not empty file is counted from its modification time,
+// so it is not reset by restarts, a creation time is not available on all platforms.
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	f.file, f.size, f.started = file, info.Size(), time.Now()
+	if f.size > 0 {
+		f.started = info.ModTime()
+	}
+
+	return nil
+}
+
+// Write implements the io.Writer interface, the file is rotated before writing if it is needed.
+// A failed rotation does not stop writing, the data is written to the reopened file and the error is returned.
+func (f *File) Write(p []byte) (int, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.closed {
+		return 0, ErrClosed
+	}
+
+	if f.file == nil {
+		// the file was not reopened after a failed rotation
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	var rotateErr error
+	if f.expired(int64(len(p))) {
+		rotateErr = f.rotate()
+	}
+
+	if f.file == nil {
+		return 0, rotateErr
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, errors.Join(err, rotateErr)
+}
+
+// Close implements the io.Closer interface.
+func (f *File) Close() error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+// expired returns true if the not empty file should be rotated before writing n bytes.
+func (f *File) expired(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+
+	if f.maxSize > 0 && f.size+n > f.maxSize {
+		return true
+	}
+
+	return f.maxAge > 0 && time.Since(f.started) > f.maxAge
+}
+
+// rotate renames the current file, opens a new one and removes the oldest rotated files.
+// The current file is reopened if it is not renamed.
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	f.file = nil
+
+	backup := f.path + "." + time.Now().UTC().Format(backupTimeFormat)
+	renameErr := os.Rename(f.path, backup)
+
+	if err := f.open(); err != nil {
+		return errors.Join(renameErr, err)
+	}
+
+	if renameErr != nil {
+		return fmt.Errorf("failed to rename file: %w", renameErr)
+	}
+
+	return f.cleanup()
+}
+
+// backups returns sorted names of rotated files, other files with the same prefix are skipped.
+func (f *File) backups() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		prefix = filepath.Base(f.path) + "."
+		names  []string
+	)
+
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		if _, err = time.Parse(backupTimeFormat, suffix); err == nil {
+			names = append(names, filepath.Join(filepath.Dir(f.path), entry.Name()))
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// cleanup removes the oldest rotated files.
+func (f *File) cleanup() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return fmt.Errorf("failed to find rotated files: %w", err)
+	}
+
+	if len(backups) <= f.maxBackups {
+		return nil
+	}
+
+	for _, name := range backups[:len(backups)-f.maxBackups] {
+		if err = os.Remove(name); err != nil {
+			return fmt.Errorf("failed to remove rotated file: %w", err)
+		}
+	}
+
+	return nil
+}
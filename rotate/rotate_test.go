@@ -0,0 +1,190 @@
+package rotate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "test.log")
+
+	f, err := New(path, 10, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err = f.Write([]byte("123456\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = f.Write([]byte("closed")); !errors.Is(err, ErrClosed) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	backups, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(backups); n != 2 {
+		t.Errorf("unexpected rotated files: %v", backups)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(data); s != "123456\n" {
+		t.Errorf("unexpected file content: %q", s)
+	}
+}
+
+func TestFileAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+
+	if err := os.WriteFile(path, []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := New(path, 0, time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if e := f.Close(); e != nil {
+			t.Error(e)
+		}
+	}()
+
+	if _, err = f.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(data); s != "old\nnew\n" {
+		t.Errorf("unexpected file content: %q", s)
+	}
+
+	f.started = time.Now().Add(-2 * time.Hour)
+	if _, err = f.Write([]byte("next\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err = os.ReadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(data); s != "next\n" {
+		t.Errorf("unexpected file content after rotation: %q", s)
+	}
+}
+
+func TestFileModTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+
+	if err := os.WriteFile(path, []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file age is not reset by reopening
+	modTime := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	// other files with the same prefix are not backups
+	other := path + ".keep"
+	if err := os.WriteFile(other, []byte("other\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := New(path, 0, time.Hour, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if e := f.Close(); e != nil {
+			t.Error(e)
+		}
+	}()
+
+	if _, err = f.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(data); s != "new\n" {
+		t.Errorf("unexpected file content: %q", s)
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backups) != 1 {
+		t.Errorf("unexpected rotated files: %v", backups)
+	}
+
+	if _, err = os.Stat(other); err != nil {
+		t.Errorf("not rotated file is removed: %v", err)
+	}
+}
+
+func TestFileRotateFailed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+
+	f, err := New(path, 10, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if e := f.Close(); e != nil {
+			t.Error(e)
+		}
+	}()
+
+	if _, err = f.Write([]byte("123456\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rotated file can not be renamed
+	if err = os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = f.Write([]byte("next\n")); err == nil {
+		t.Error("expected rotation error")
+	}
+
+	if _, err = f.Write([]byte("last\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(data); s != "next\nlast\n" {
+		t.Errorf("unexpected file content: %q", s)
+	}
+}
@@ -0,0 +1,206 @@
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Types of detected secrets.
+const (
+	TypePrivateKey     = "private_key"
+	TypeAWSAccessKey   = "aws_access_key"
+	TypeAWSSecretKey   = "aws_secret_key"
+	TypeJWT            = "jwt"
+	TypeTelegramToken  = "telegram_token"
+	TypeYandexAPIKey   = "yandex_api_key"
+	TypeYandexIAMToken = "yandex_iam_token"
+	TypeHighEntropy    = "high_entropy"
+)
+
+// High entropy tokens limits.
+const (
+	minEntropy       = 4.0 // minimum Shannon entropy in bits per character
+	minEntropyDigits = 2   // minimum number of digits in a token
+)
+
+// Finding is a detected secret position in a text.
+type Finding struct {
+	Type  string
+	Start int // start byte offset
+	End   int // end byte offset
+}
+
+// rule is a known credential format, only the group is masked if it is positive.
+type rule struct {
+	name  string
+	re    *regexp.Regexp
+	group int
+	valid func(string) bool // optional check of found values
+}
+
+// rules are secret detection rules, the order matters: earlier found secrets are not checked again.
+var rules = []rule{
+	{
+		name: TypePrivateKey,
+		re:   regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----(?s:.*?)(?:-----END [A-Z ]*PRIVATE KEY( BLOCK)?-----|$)`),
+	},
+	{
+		name: TypeAWSAccessKey,
+		re:   regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[A-Z0-9]{16}\b`),
+	},
+	{
+		name:  TypeAWSSecretKey,
+		re:    regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`),
+		group: 1,
+	},
+	{
+		name: TypeJWT,
+		re:   regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{5,}\.eyJ[A-Za-z0-9_\-]{5,}\.[A-Za-z0-9_\-]*`),
+	},
+	{
+		name: TypeTelegramToken,
+		re:   regexp.MustCompile(`\b\d{8,10}:AA[A-Za-z0-9_\-]{33}`),
+	},
+	{
+		name: TypeYandexAPIKey,
+		re:   regexp.MustCompile(`\bAQVN[A-Za-z0-9_\-]{35,38}`),
+	},
+	{
+		name: TypeYandexIAMToken,
+		re:   regexp.MustCompile(`\bt1\.[A-Za-z0-9_\-]+=*\.[A-Za-z0-9_\-]{80,}=*`),
+	},
+	{
+		name:  TypeHighEntropy,
+		re:    regexp.MustCompile(`[A-Za-z0-9+=_\-]{20,}`),
+		valid: highEntropy,
+	},
+}
+
+// Scan returns found secrets ordered by their positions.
+func Scan(text string) []Finding {
+	var findings []Finding
+
+	for _, r := range rules {
+		for _, m := range r.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if r.group > 0 {
+				start, end = m[2*r.group], m[2*r.group+1]
+			}
+
+			if start < 0 || overlaps(findings, start, end) {
+				continue
+			}
+
+			if r.valid != nil && !r.valid(text[start:end]) {
+				continue
+			}
+
+			findings = append(findings, Finding{Type: r.name, Start: start, End: end})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Start < findings[j].Start
+	})
+
+	return findings
+}
+
+// Mask returns the text with found secrets replaced by their type placeholders.
+func Mask(text string) (string, []Finding) {
+	findings := Scan(text)
+	if len(findings) == 0 {
+		return text, nil
+	}
+
+	var (
+		b    strings.Builder
+		prev int
+	)
+
+	for _, f := range findings {
+		b.WriteString(text[prev:f.Start])
+		b.WriteString("[" + strings.ToUpper(f.Type) + "]")
+		prev = f.End
+	}
+
+	b.WriteString(text[prev:])
+	return b.String(), findings
+}
+
+// Types returns unique types of the findings in order of their appearance.
+func Types(findings []Finding) []string {
+	var (
+		types = make([]string, 0, len(findings))
+		seen  = make(map[string]bool, len(findings))
+	)
+
+	for _, f := range findings {
+		if !seen[f.Type] {
+			seen[f.Type] = true
+			types = append(types, f.Type)
+		}
+	}
+
+	return types
+}
+
+// overlaps returns true if the range intersects any of the findings.
+func overlaps(findings []Finding, start, end int) bool {
+	for _, f := range findings {
+		if start < f.End && f.Start < end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// highEntropy returns true if the token looks like a random secret:
+// it has upper and lower case letters, several digits, it is not hexadecimal, and its entropy is high.
+func highEntropy(token string) bool {
+	var (
+		upper, lower bool
+		digits       int
+		hex          = true
+		counts       = make(map[rune]int)
+	)
+
+	for _, r := range token {
+		counts[r]++
+
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digits++
+		}
+
+		if !strings.ContainsRune("0123456789abcdefABCDEF-", r) {
+			hex = false
+		}
+	}
+
+	if !upper || !lower || digits < minEntropyDigits || hex {
+		return false
+	}
+
+	return entropy(counts, len(token)) >= minEntropy
+}
+
+// entropy returns Shannon entropy in bits per character of the characters counts.
+func entropy(counts map[rune]int, length int) float64 {
+	var result float64
+
+	for _, n := range counts {
+		p := float64(n) / float64(length)
+		result -= p * math.Log2(p)
+	}
+
+	return result
+}
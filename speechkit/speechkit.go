@@ -0,0 +1,141 @@
+package speechkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/z0rr0/tgtpgybot/apierror"
+)
+
+// RecognizeURL is a synchronous speech recognition API URL.
+const RecognizeURL = "https://stt.api.cloud.yandex.net/speech/v1/stt:recognize"
+
+// Synchronous recognition limits.
+const (
+	MaxAudioSize     = 1 << 20 // 1 MB
+	MaxAudioDuration = 30 * time.Second
+)
+
+// FormatOggOpus is OGG container with Opus codec, Telegram voice messages use it.
+const FormatOggOpus = "oggopus"
+
+var (
+	// ErrRequiredParam is an error that occurs when a required parameter is missing.
+	ErrRequiredParam = errors.New("required parameter is missing")
+
+	// ErrRecognition is an error that occurs when a speech recognition request fails.
+	ErrRecognition = errors.New("failed to recognize speech")
+)
+
+// RecognizeRequest is a request params structure for the speech recognition API.
+type RecognizeRequest struct {
+	APIKey string
+	URL    string
+	Lang   string // optional, API uses "ru-RU" by default
+	Audio  io.Reader
+}
+
+// RecognizeResponse is a response from the speech recognition API.
+type RecognizeResponse struct {
+	Result       string `json:"result"`
+	ErrorCode    string `json:"error_code,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// String implements the fmt.Stringer interface.
+func (rr *RecognizeResponse) String() string {
+	return rr.Result
+}
+
+func (r *RecognizeRequest) validate() error {
+	if r.APIKey == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("APIKey is empty"))
+	}
+
+	if r.URL == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("URL is empty"))
+	}
+
+	if r.Audio == nil {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("audio is empty"))
+	}
+
+	return nil
+}
+
+// build returns a new http.Request.
+func (r *RecognizeRequest) build(ctx context.Context) (*http.Request, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	params := u.Query()
+	params.Set("format", FormatOggOpus)
+
+	if r.Lang != "" {
+		params.Set("lang", r.Lang)
+	}
+
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), r.Audio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Api-Key "+r.APIKey)
+
+	return req, nil
+}
+
+// Recognize returns a recognized text of the audio data.
+func Recognize(ctx context.Context, client *http.Client, req *RecognizeRequest) (*RecognizeResponse, error) {
+	request, err := req.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Join(ErrRecognition, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apierror.New(ErrRecognition, resp.StatusCode, resp.Body)
+	}
+
+	return buildResponse(resp.Body)
+}
+
+func buildResponse(reader io.Reader) (*RecognizeResponse, error) {
+	response := &RecognizeResponse{}
+	if err := json.NewDecoder(reader).Decode(response); err != nil {
+		return nil, errors.Join(ErrRecognition, err)
+	}
+
+	if response.ErrorCode != "" {
+		return nil, errors.Join(
+			ErrRecognition,
+			fmt.Errorf("error code=%v: %v", response.ErrorCode, response.ErrorMessage),
+		)
+	}
+
+	return response, nil
+}
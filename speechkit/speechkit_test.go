@@ -0,0 +1,129 @@
+package speechkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecognize(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Api-Key test-key" {
+			t.Errorf("failed authorization header: %q", auth)
+		}
+
+		if format := r.URL.Query().Get("format"); format != FormatOggOpus {
+			t.Errorf("failed format: %q", format)
+		}
+
+		if lang := r.URL.Query().Get("lang"); lang != "en-US" {
+			t.Errorf("failed lang: %q", lang)
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if b := string(body); b != "audio" {
+			t.Errorf("failed body: %q", b)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err = fmt.Fprint(w, `{"result":"Кто ты?"}`); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	req := &RecognizeRequest{APIKey: "test-key", URL: s.URL, Lang: "en-US", Audio: strings.NewReader("audio")}
+
+	resp, err := Recognize(context.Background(), s.Client(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r := resp.String(); r != "Кто ты?" {
+		t.Errorf("unexpected result: %q", r)
+	}
+}
+
+func TestRecognizeFailed(t *testing.T) {
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		expectedPrefix string
+	}{
+		{
+			name: "status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "test", http.StatusBadGateway)
+			},
+			expectedPrefix: "failed to recognize speech\nunexpected status code",
+		},
+		{
+			name: "json",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, `{"result`)
+			},
+			expectedPrefix: "failed to recognize speech",
+		},
+		{
+			name: "errorCode",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, `{"error_code":"BAD_REQUEST","error_message":"audio should be less than 30s"}`)
+			},
+			expectedPrefix: "failed to recognize speech\nerror code=BAD_REQUEST",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(tc.handler)
+			defer s.Close()
+
+			req := &RecognizeRequest{APIKey: "test-key", URL: s.URL, Audio: strings.NewReader("audio")}
+
+			_, err := Recognize(context.Background(), s.Client(), req)
+			if !errors.Is(err, ErrRecognition) {
+				t.Fatalf("expected error: %v, got: %v", ErrRecognition, err)
+			}
+
+			if e := err.Error(); !strings.HasPrefix(e, tc.expectedPrefix) {
+				t.Fatalf("expected %q, got %q", tc.expectedPrefix, e)
+			}
+		})
+	}
+}
+
+func TestRecognizeValidate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		req       RecognizeRequest
+		errSubStr string
+	}{
+		{name: "empty", req: RecognizeRequest{}, errSubStr: "APIKey is empty"},
+		{name: "noURL", req: RecognizeRequest{APIKey: "test-key"}, errSubStr: "URL is empty"},
+		{name: "noAudio", req: RecognizeRequest{APIKey: "test-key", URL: RecognizeURL}, errSubStr: "audio is empty"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.req.build(context.Background())
+			if !errors.Is(err, ErrRequiredParam) {
+				t.Fatalf("expected error: %v, got: %v", ErrRequiredParam, err)
+			}
+
+			if !strings.Contains(err.Error(), tc.errSubStr) {
+				t.Fatalf("expected error: %v, got: %v", tc.errSubStr, err)
+			}
+		})
+	}
+}
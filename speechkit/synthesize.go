@@ -0,0 +1,168 @@
+package speechkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/z0rr0/tgtpgybot/apierror"
+)
+
+// SynthesizeURL is a speech synthesis API URL.
+const SynthesizeURL = "https://tts.api.cloud.yandex.net/speech/v1/tts:synthesize"
+
+// MaxTextLength is a maximum number of characters for the speech synthesis.
+const MaxTextLength = 5000
+
+// ErrSynthesis is an error that occurs when a speech synthesis request fails.
+var ErrSynthesis = errors.New("failed to synthesize speech")
+
+// SynthesizeRequest is a request params structure for the speech synthesis API.
+type SynthesizeRequest struct {
+	APIKey string
+	URL    string
+	Text   string
+	Lang   string  // optional, API uses "ru-RU" by default
+	Voice  string  // optional, API uses its default voice
+	Speed  float64 // optional, API uses 1.0 by default
+}
+
+func (r *SynthesizeRequest) validate() error {
+	if r.APIKey == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("APIKey is empty"))
+	}
+
+	if r.URL == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("URL is empty"))
+	}
+
+	if r.Text == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("text is empty"))
+	}
+
+	if n := utf8.RuneCountInString(r.Text); n > MaxTextLength {
+		return errors.Join(ErrSynthesis, fmt.Errorf("text is too long: %d characters", n))
+	}
+
+	return nil
+}
+
+// build returns a new http.Request.
+func (r *SynthesizeRequest) build(ctx context.Context) (*http.Request, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{"text": {r.Text}, "format": {FormatOggOpus}}
+
+	if r.Lang != "" {
+		params.Set("lang", r.Lang)
+	}
+
+	if r.Voice != "" {
+		params.Set("voice", r.Voice)
+	}
+
+	if r.Speed > 0 {
+		params.Set("speed", strconv.FormatFloat(r.Speed, 'f', -1, 64))
+	}
+
+	body := strings.NewReader(params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Api-Key "+r.APIKey)
+
+	return req, nil
+}
+
+// Synthesize returns OGG/Opus audio data of the synthesized speech.
+func Synthesize(ctx context.Context, client *http.Client, req *SynthesizeRequest) ([]byte, error) {
+	request, err := req.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Join(ErrSynthesis, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apierror.New(ErrSynthesis, resp.StatusCode, resp.Body)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Join(ErrSynthesis, err)
+	}
+
+	return data, nil
+}
+
+// SplitText splits the text into parts of no more than MaxTextLength characters for the speech synthesis.
+// Parts end at sentence boundaries if possible, then at spaces.
+func SplitText(text string) []string {
+	var (
+		parts []string
+		runes = []rune(strings.TrimSpace(text))
+	)
+
+	for len(runes) > MaxTextLength {
+		n := splitIndex(runes)
+		if part := strings.TrimSpace(string(runes[:n])); part != "" {
+			parts = append(parts, part)
+		}
+
+		for runes = runes[n:]; len(runes) > 0 && unicode.IsSpace(runes[0]); {
+			runes = runes[1:]
+		}
+	}
+
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+
+	return parts
+}
+
+// splitIndex returns a length of the first part of the long text,
+// it is the last sentence end or space within MaxTextLength characters.
+func splitIndex(runes []rune) int {
+	space := 0
+
+	for i := MaxTextLength; i > 0; i-- {
+		if !unicode.IsSpace(runes[i]) {
+			continue
+		}
+
+		if strings.ContainsRune(".!?…\n", runes[i-1]) || runes[i] == '\n' {
+			return i
+		}
+
+		if space == 0 {
+			space = i
+		}
+	}
+
+	if space > 0 {
+		return space
+	}
+
+	return MaxTextLength
+}
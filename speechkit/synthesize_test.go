@@ -0,0 +1,126 @@
+package speechkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSynthesize(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Api-Key test-key" {
+			t.Errorf("failed authorization header: %q", auth)
+		}
+
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+
+		expected := map[string]string{
+			"text":   "Меня зовут Алиса",
+			"format": FormatOggOpus,
+			"lang":   "ru-RU",
+			"voice":  "alena",
+			"speed":  "1.2",
+		}
+
+		for key, value := range expected {
+			if v := r.PostForm.Get(key); v != value {
+				t.Errorf("failed param %q: %q", key, v)
+			}
+		}
+
+		if _, err := fmt.Fprint(w, "OggS"); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	req := &SynthesizeRequest{
+		APIKey: "test-key",
+		URL:    s.URL,
+		Text:   "Меня зовут Алиса",
+		Lang:   "ru-RU",
+		Voice:  "alena",
+		Speed:  1.2,
+	}
+
+	data, err := Synthesize(context.Background(), s.Client(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if d := string(data); d != "OggS" {
+		t.Errorf("unexpected data: %q", d)
+	}
+}
+
+func TestSynthesizeFailed(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "test", http.StatusBadRequest)
+	}))
+	defer s.Close()
+
+	req := &SynthesizeRequest{APIKey: "test-key", URL: s.URL, Text: "test"}
+
+	_, err := Synthesize(context.Background(), s.Client(), req)
+	if !errors.Is(err, ErrSynthesis) {
+		t.Fatalf("expected error: %v, got: %v", ErrSynthesis, err)
+	}
+
+	expectedPrefix := "failed to synthesize speech\nunexpected status code"
+	if e := err.Error(); !strings.HasPrefix(e, expectedPrefix) {
+		t.Fatalf("expected %q, got %q", expectedPrefix, e)
+	}
+
+	req.Text = strings.Repeat("a", MaxTextLength+1)
+
+	_, err = Synthesize(context.Background(), s.Client(), req)
+	if !errors.Is(err, ErrSynthesis) {
+		t.Fatalf("expected error: %v, got: %v", ErrSynthesis, err)
+	}
+
+	req.Text = ""
+
+	_, err = Synthesize(context.Background(), s.Client(), req)
+	if !errors.Is(err, ErrRequiredParam) {
+		t.Fatalf("expected error: %v, got: %v", ErrRequiredParam, err)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	sentence := strings.Repeat("a", 99) + ". "
+	words := strings.Repeat("слово ", 1000)
+
+	testCases := []struct {
+		name  string
+		text  string
+		parts []int // lengths of parts in characters
+	}{
+		{name: "empty", text: " "},
+		{name: "short", text: " Кто ты? ", parts: []int{7}},
+		{name: "sentences", text: strings.Repeat(sentence, 60), parts: []int{4948, 1110}},
+		{name: "words", text: words, parts: []int{4997, 1001}},
+		{name: "long word", text: strings.Repeat("ы", MaxTextLength+1), parts: []int{MaxTextLength, 1}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			parts := SplitText(tc.text)
+			if len(parts) != len(tc.parts) {
+				t.Fatalf("unexpected parts number: %d", len(parts))
+			}
+
+			for j, part := range parts {
+				if n := len([]rune(part)); n != tc.parts[j] {
+					t.Errorf("unexpected part %d length: %d", j, n)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+// Package telegramtest provides a fake Telegram Bot API server for end-to-end tests.
+// It records all API calls and delivers queued updates by long polling.
+package telegramtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/telebot.v3"
+)
+
+// BotID is a user ID of the test bot.
+const BotID = 100
+
+// pollTimeout is a maximum waiting time of getUpdates requests,
+// it is short so that the bot can stop quickly.
+const pollTimeout = 100 * time.Millisecond
+
+// ErrTimeout is an error if expected calls are not received in time.
+var ErrTimeout = errors.New("calls waiting timeout")
+
+// Call is a recorded Bot API call.
+type Call struct {
+	Method string
+	Params map[string]string
+}
+
+// Server is a fake Telegram Bot API server.
+type Server struct {
+	*httptest.Server
+	sync.Mutex
+	token     string
+	calls     []Call
+	updates   []telebot.Update
+	files     map[string][]byte // file_id -> content
+	updateID  int
+	messageID int
+	changed   chan struct{} // it is closed and replaced when new updates or calls are added
+}
+
+// NewServer starts a new fake Bot API server for the bot token.
+// The returned server's URL should be used as telebot.Settings.URL.
+func NewServer(token string) *Server {
+	s := &Server{token: token, files: make(map[string][]byte), changed: make(chan struct{})}
+	s.Server = httptest.NewServer(s)
+
+	return s
+}
+
+// AddMessage queues an update with a new text message from the user and returns the message ID.
+func (s *Server) AddMessage(chatID, userID int64, text string) int {
+	s.Lock()
+	defer s.Unlock()
+
+	s.messageID++
+	message := &telebot.Message{
+		ID:       s.messageID,
+		Sender:   &telebot.User{ID: userID, Username: "user" + strconv.FormatInt(userID, 10)},
+		Chat:     &telebot.Chat{ID: chatID, Type: telebot.ChatPrivate},
+		Unixtime: time.Now().Unix(),
+		Text:     text,
+	}
+
+	if strings.HasPrefix(text, "/") {
+		command := strings.Fields(text)[0]
+		message.Entities = telebot.Entities{{Type: telebot.EntityCommand, Offset: 0, Length: len(command)}}
+	}
+
+	s.addUpdate(telebot.Update{Message: message})
+	return message.ID
+}
+
+// AddUpdate queues the update, its ID is set by the server.
+func (s *Server) AddUpdate(u telebot.Update) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.addUpdate(u)
+}
+
+// AddFile saves the file content to download it by the file ID.
+func (s *Server) AddFile(fileID string, data []byte) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.files[fileID] = data
+}
+
+// Calls returns recorded calls of the method, all calls are returned if the method is empty.
+func (s *Server) Calls(method string) []Call {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.filter(method)
+}
+
+// LastText returns a text of the latest sent or edited message, it is empty if there are no such calls.
+func (s *Server) LastText() string {
+	s.Lock()
+	defer s.Unlock()
+
+	for i := len(s.calls) - 1; i >= 0; i-- {
+		if c := s.calls[i]; c.Method == "sendMessage" || c.Method == "editMessageText" {
+			return c.Params["text"]
+		}
+	}
+
+	return ""
+}
+
+// WaitCalls waits until there are at least n calls of the method and returns them.
+func (s *Server) WaitCalls(method string, n int, timeout time.Duration) ([]Call, error) {
+	deadline := time.After(timeout)
+
+	for {
+		s.Lock()
+		calls, changed := s.filter(method), s.changed
+		s.Unlock()
+
+		if len(calls) >= n {
+			return calls, nil
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return calls, errors.Join(ErrTimeout, fmt.Errorf("method %q: %d calls of %d", method, len(calls), n))
+		}
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if fileID, ok := strings.CutPrefix(r.URL.Path, "/file/bot"+s.token+"/"); ok {
+		s.serveFile(w, fileID)
+		return
+	}
+
+	method, ok := strings.CutPrefix(r.URL.Path, "/bot"+s.token+"/")
+	if !ok {
+		s.write(w, http.StatusUnauthorized, map[string]any{"ok": false, "error_code": 401, "description": "Unauthorized"})
+		return
+	}
+
+	params, err := parseParams(r)
+	if err != nil {
+		s.write(w, http.StatusBadRequest, map[string]any{"ok": false, "error_code": 400, "description": err.Error()})
+		return
+	}
+
+	if method == "getUpdates" {
+		s.getUpdates(w, r, params)
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.record(Call{Method: method, Params: params})
+
+	result, err := s.result(method, params)
+	if err != nil {
+		s.write(w, http.StatusNotFound, map[string]any{"ok": false, "error_code": 404, "description": err.Error()})
+		return
+	}
+
+	s.write(w, http.StatusOK, map[string]any{"ok": true, "result": result})
+}
+
+// result returns a response result of the method.
+func (s *Server) result(method string, params map[string]string) (any, error) {
+	switch method {
+	case "getMe":
+		return &telebot.User{ID: BotID, IsBot: true, Username: "test_bot"}, nil
+	case "sendMessage", "sendDocument", "sendVoice", "sendPhoto":
+		s.messageID++
+		return s.message(s.messageID, params), nil
+	case "editMessageText":
+		messageID, err := strconv.Atoi(params["message_id"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid message_id: %w", err)
+		}
+		return s.message(messageID, params), nil
+	case "getFile":
+		fileID := params["file_id"]
+		if _, ok := s.files[fileID]; !ok {
+			return nil, fmt.Errorf("file %q is not found", fileID)
+		}
+		return &telebot.File{FileID: fileID, UniqueID: fileID, FilePath: fileID}, nil
+	case "sendChatAction", "setMyCommands", "answerCallbackQuery", "deleteMessage":
+		return true, nil
+	default:
+		return nil, fmt.Errorf("method %q is not supported", method)
+	}
+}
+
+// message returns a bot's message for send or edit methods.
+func (s *Server) message(messageID int, params map[string]string) *telebot.Message {
+	chatID, _ := strconv.ParseInt(params["chat_id"], 10, 64)
+
+	return &telebot.Message{
+		ID:       messageID,
+		Sender:   &telebot.User{ID: BotID, IsBot: true, Username: "test_bot"},
+		Chat:     &telebot.Chat{ID: chatID, Type: telebot.ChatPrivate},
+		Unixtime: time.Now().Unix(),
+		Text:     params["text"],
+	}
+}
+
+// getUpdates returns queued updates starting from the offset, it waits for new ones for a short time.
+func (s *Server) getUpdates(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	offset, _ := strconv.Atoi(params["offset"])
+	timer := time.NewTimer(pollTimeout)
+	defer timer.Stop()
+
+	for {
+		s.Lock()
+		var updates []telebot.Update
+
+		for _, u := range s.updates {
+			if u.ID >= offset {
+				updates = append(updates, u)
+			}
+		}
+		changed := s.changed
+		s.Unlock()
+
+		if len(updates) > 0 {
+			s.write(w, http.StatusOK, map[string]any{"ok": true, "result": updates})
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			s.write(w, http.StatusOK, map[string]any{"ok": true, "result": []telebot.Update{}})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// serveFile writes the file content.
+func (s *Server) serveFile(w http.ResponseWriter, fileID string) {
+	s.Lock()
+	data, ok := s.files[fileID]
+	s.Unlock()
+
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+
+	_, _ = w.Write(data)
+}
+
+// addUpdate sets the update ID and queues it.
+func (s *Server) addUpdate(u telebot.Update) {
+	s.updateID++
+	u.ID = s.updateID
+	s.updates = append(s.updates, u)
+	s.notify()
+}
+
+// record saves the call.
+func (s *Server) record(c Call) {
+	s.calls = append(s.calls, c)
+	s.notify()
+}
+
+// notify wakes up waiting requests.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// filter returns the calls of the method.
+func (s *Server) filter(method string) []Call {
+	var calls []Call
+
+	for _, c := range s.calls {
+		if method == "" || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+
+	return calls
+}
+
+// write sends JSON response.
+func (s *Server) write(w http.ResponseWriter, status int, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// parseParams returns request parameters of JSON or multipart form body.
+func parseParams(r *http.Request) (map[string]string, error) {
+	params := make(map[string]string)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			return nil, fmt.Errorf("failed to parse form: %w", err)
+		}
+
+		for key, values := range r.MultipartForm.Value {
+			params[key] = values[0]
+		}
+
+		for key := range r.MultipartForm.File {
+			params[key] = "file"
+		}
+
+		return params, nil
+	}
+
+	raw := make(map[string]any)
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse body: %w", err)
+	}
+
+	for key, value := range raw {
+		if s, ok := value.(string); ok {
+			params[key] = s
+			continue
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal param %q: %w", key, err)
+		}
+		params[key] = string(data)
+	}
+
+	return params, nil
+}
@@ -0,0 +1,143 @@
+package telegramtest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"gopkg.in/telebot.v3"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer("test-token")
+	defer s.Close()
+
+	b, err := telebot.NewBot(telebot.Settings{Token: "test-token", URL: s.URL, Offline: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.Me.ID != BotID {
+		t.Errorf("unexpected bot: %+v", b.Me)
+	}
+
+	chat := &telebot.Chat{ID: 1}
+	if text := s.LastText(); text != "" {
+		t.Errorf("unexpected last text: %q", text)
+	}
+
+	m, err := b.Send(chat, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = b.Edit(m, "hello, world"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = b.Notify(chat, telebot.Typing); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = b.SetCommands([]telebot.Command{{Text: "cancel", Description: "cancel generations"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.AddFile("voice", []byte("OggS"))
+	reader, err := b.File(&telebot.File{FileID: "voice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "OggS" {
+		t.Errorf("unexpected file: %q", data)
+	}
+
+	if _, err = b.File(&telebot.File{FileID: "unknown"}); err == nil {
+		t.Error("expected error")
+	}
+
+	if text := s.LastText(); text != "hello, world" {
+		t.Errorf("unexpected last text: %q", text)
+	}
+
+	edits := s.Calls("editMessageText")
+	if len(edits) != 1 || edits[0].Params["text"] != "hello, world" {
+		t.Errorf("unexpected edits: %v", edits)
+	}
+
+	expected := []string{"getMe", "sendMessage", "editMessageText", "sendChatAction", "setMyCommands", "getFile", "getFile"}
+	calls := s.Calls("")
+
+	if len(calls) != len(expected) {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+
+	for i, c := range calls {
+		if c.Method != expected[i] {
+			t.Errorf("unexpected call %d: %q", i, c.Method)
+		}
+	}
+
+	if _, err = s.WaitCalls("sendVoice", 1, 10*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServerUpdates(t *testing.T) {
+	s := NewServer("test-token")
+	defer s.Close()
+
+	b, err := telebot.NewBot(telebot.Settings{Token: "test-token", URL: s.URL, Offline: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getUpdates := func(offset int) []telebot.Update {
+		data, e := b.Raw("getUpdates", map[string]string{"offset": strconv.Itoa(offset), "timeout": "30"})
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		var resp struct {
+			Result []telebot.Update `json:"result"`
+		}
+		if e = json.Unmarshal(data, &resp); e != nil {
+			t.Fatal(e)
+		}
+
+		return resp.Result
+	}
+
+	if updates := getUpdates(0); len(updates) != 0 {
+		t.Errorf("unexpected updates: %v", updates)
+	}
+
+	id := s.AddMessage(1, 2, "/cancel now")
+	updates := getUpdates(0)
+
+	if len(updates) != 1 {
+		t.Fatalf("unexpected updates: %v", updates)
+	}
+
+	m := updates[0].Message
+	if m.ID != id || m.Text != "/cancel now" || m.Sender.ID != 2 || m.Chat.ID != 1 || len(m.Entities) != 1 {
+		t.Errorf("unexpected message: %+v", m)
+	}
+
+	if updates = getUpdates(updates[0].ID + 1); len(updates) != 0 {
+		t.Errorf("unexpected updates after offset: %v", updates)
+	}
+}
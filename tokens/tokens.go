@@ -0,0 +1,94 @@
+package tokens
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/z0rr0/tgtpgybot/cache"
+)
+
+// Counted texts cache limits.
+const (
+	cacheSize = 4096
+	cacheTTL  = 24 * time.Hour
+)
+
+// charsPerToken is an estimated number of word characters in a token.
+const charsPerToken = 4
+
+// TokenizeFunc returns a number of the text tokens by the model tokenizer.
+type TokenizeFunc func(ctx context.Context, text string) (int64, error)
+
+// Counter counts text tokens by the model tokenizer with fallback to the local estimation.
+type Counter struct {
+	tokenize TokenizeFunc
+	cache    *cache.Cache[int64]
+}
+
+// New returns a new counter, texts are only estimated if the tokenizer is nil.
+// Tokenized counts are cached, so repeated texts like the conversation history are not sent again.
+func New(tokenize TokenizeFunc) *Counter {
+	c := &Counter{tokenize: tokenize}
+
+	if tokenize != nil {
+		c.cache = cache.New[int64](cacheSize, cacheTTL)
+	}
+
+	return c
+}
+
+// Count returns a number of the text tokens,
+// the local estimation is used if the tokenizer is not set or failed.
+func (c *Counter) Count(ctx context.Context, text string) int64 {
+	if c.tokenize == nil || text == "" {
+		return Estimate(text)
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	key := hex.EncodeToString(sum[:])
+
+	if n, ok := c.cache.Get(key); ok {
+		return n
+	}
+
+	n, err := c.tokenize(ctx, text)
+	if err != nil {
+		slog.Warn("failed to tokenize, the estimation is used", "error", err)
+		return Estimate(text)
+	}
+
+	c.cache.Set(key, n)
+	return n
+}
+
+// Estimate returns an approximate number of the text tokens:
+// a token per every started charsPerToken characters of a word and a token per other symbol.
+func Estimate(text string) int64 {
+	var n int64
+
+	for _, word := range strings.FieldsFunc(text, unicode.IsSpace) {
+		var chars int
+
+		for _, r := range word {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				chars++
+				continue
+			}
+
+			if chars > 0 {
+				n += int64((chars + charsPerToken - 1) / charsPerToken)
+				chars = 0
+			}
+			n++
+		}
+
+		n += int64((chars + charsPerToken - 1) / charsPerToken)
+	}
+
+	return n
+}
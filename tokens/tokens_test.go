@@ -0,0 +1,61 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected int64
+	}{
+		{text: "", expected: 0},
+		{text: "   \n", expected: 0},
+		{text: "Кто ты?", expected: 3},
+		{text: "internationalization", expected: 5},
+		{text: "a,b c", expected: 4},
+		{text: "func main() {}", expected: 6},
+	}
+
+	for _, tc := range testCases {
+		if n := Estimate(tc.text); n != tc.expected {
+			t.Errorf("unexpected estimation of %q: %d", tc.text, n)
+		}
+	}
+}
+
+func TestCounter(t *testing.T) {
+	var calls int
+	c := New(func(_ context.Context, text string) (int64, error) {
+		calls++
+		if text == "fail" {
+			return 0, errors.New("tokenizer error")
+		}
+		return 42, nil
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if n := c.Count(ctx, "some text"); n != 42 {
+			t.Errorf("unexpected count: %d", n)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("count is not cached: %d calls", calls)
+	}
+
+	if n := c.Count(ctx, "fail"); n != Estimate("fail") {
+		t.Errorf("unexpected fallback count: %d", n)
+	}
+
+	if n := c.Count(ctx, ""); n != 0 || calls != 2 {
+		t.Errorf("unexpected empty text count: %d, %d calls", n, calls)
+	}
+
+	if n := New(nil).Count(ctx, "Кто ты?"); n != 3 {
+		t.Errorf("unexpected estimation: %d", n)
+	}
+}
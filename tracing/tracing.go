@@ -0,0 +1,78 @@
+// Package tracing configures OpenTelemetry tracing of Telegram updates and external API calls.
+// Spans are exported by OTLP/HTTP, the global no-op provider is used if tracing is not started.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name is an instrumentation name of the bot's tracer.
+const Name = "github.com/z0rr0/tgtpgybot"
+
+// Common span attributes.
+const (
+	AttrModel       = attribute.Key("ygpt.model")
+	AttrTemperature = attribute.Key("ygpt.temperature")
+	AttrMaxTokens   = attribute.Key("ygpt.max_tokens")
+	AttrTokens      = attribute.Key("ygpt.tokens")
+	AttrCached      = attribute.Key("ygpt.cached")
+)
+
+// Tracer returns the bot's tracer of the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(Name)
+}
+
+// Transport wraps the HTTP transport to create a client span for every request.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
+
+// Fail records the error and sets the error status of the span.
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// New sets a global tracer provider that exports spans by OTLP/HTTP to the endpoint URL,
+// for example "http://localhost:4318/v1/traces". The provider should be shut down to flush spans.
+func New(ctx context.Context, endpoint, serviceName string) (*sdktrace.TracerProvider, error) {
+	// the exporter only logs invalid URLs and falls back to the default endpoint
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse endpoint: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported endpoint scheme %q", u.Scheme)
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exporter: %w", err)
+	}
+
+	return NewProvider(serviceName, sdktrace.WithBatcher(exporter)), nil
+}
+
+// NewProvider sets a global tracer provider of the service with custom options,
+// for example tests use a synchronous in-memory exporter.
+func NewProvider(serviceName string, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(attribute.String("service.name", serviceName))
+	provider := sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, opts...)...)
+
+	otel.SetTracerProvider(provider)
+	return provider
+}
@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTransport(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider("test", sdktrace.WithSyncer(exporter))
+	defer func() {
+		if err := provider.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer s.Close()
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	client := &http.Client{Transport: Transport(s.Client().Transport)}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if n := len(spans); n != 2 {
+		t.Fatalf("unexpected number of spans: %d", n)
+	}
+
+	child, root := spans[0], spans[1]
+	if root.Name != "parent" {
+		t.Errorf("unexpected root span: %q", root.Name)
+	}
+
+	if child.Parent.SpanID() != root.SpanContext.SpanID() {
+		t.Errorf("HTTP span is not a child: %v", child.Parent)
+	}
+
+	if name := root.Resource.Attributes()[0]; name.Value.AsString() != "test" {
+		t.Errorf("unexpected service name: %v", name)
+	}
+}
+
+func TestFail(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider("test", sdktrace.WithSyncer(exporter))
+	defer func() {
+		if err := provider.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	_, span := Tracer().Start(context.Background(), "failed")
+	Fail(span, errors.New("test error"))
+	span.End()
+
+	spans := exporter.GetSpans()
+	if n := len(spans); n != 1 {
+		t.Fatalf("unexpected number of spans: %d", n)
+	}
+
+	if s := spans[0].Status; s.Code != codes.Error || s.Description != "test error" {
+		t.Errorf("unexpected status: %+v", s)
+	}
+
+	if n := len(spans[0].Events); n != 1 {
+		t.Errorf("unexpected number of events: %d", n)
+	}
+}
+
+func TestNew(t *testing.T) {
+	provider, err := New(context.Background(), "http://localhost:4318/v1/traces", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = provider.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+	for _, endpoint := range []string{"://bad", "localhost:4318"} {
+		if _, err = New(context.Background(), endpoint, "test"); err == nil {
+			t.Errorf("expected error for %q", endpoint)
+		}
+	}
+}
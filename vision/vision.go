@@ -0,0 +1,169 @@
+package vision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/z0rr0/tgtpgybot/apierror"
+)
+
+// OCRURL is a text recognition API URL.
+const OCRURL = "https://ocr.api.cloud.yandex.net/ocr/v1/recognizeText"
+
+// MaxImageSize is a maximum image size for the text recognition.
+const MaxImageSize = 10 << 20 // 10 MB
+
+// Image MIME types.
+const (
+	MimeTypeJPEG = "JPEG"
+	MimeTypePNG  = "PNG"
+)
+
+// ModelPage is a text recognition model for the most of images.
+const ModelPage = "page"
+
+var (
+	// ErrRequiredParam is an error that occurs when a required parameter is missing.
+	ErrRequiredParam = errors.New("required parameter is missing")
+
+	// ErrTextRecognition is an error that occurs when a text recognition request fails.
+	ErrTextRecognition = errors.New("failed to recognize text")
+)
+
+// TextRecognition is a request to the text recognition API.
+type TextRecognition struct {
+	MimeType      string   `json:"mimeType"`
+	LanguageCodes []string `json:"languageCodes"`
+	Model         string   `json:"model"`
+	Content       []byte   `json:"content"` // base64 encoded by JSON marshal
+}
+
+// TextAnnotation is a recognized text.
+type TextAnnotation struct {
+	FullText string `json:"fullText"`
+}
+
+// OCRResult is a result of the text recognition API.
+type OCRResult struct {
+	TextAnnotation TextAnnotation `json:"textAnnotation"`
+}
+
+// OCRResponse is a response from the text recognition API.
+type OCRResponse struct {
+	Result OCRResult `json:"result"`
+}
+
+// String implements the fmt.Stringer interface.
+func (or *OCRResponse) String() string {
+	return or.Result.TextAnnotation.FullText
+}
+
+// OCRRequest is a request params structure for the text recognition API.
+type OCRRequest struct {
+	APIKey    string
+	URL       string
+	MimeType  string   // optional, MimeTypeJPEG by default
+	Languages []string // optional, any language by default
+	Image     []byte
+}
+
+func (r *OCRRequest) validate() error {
+	if r.APIKey == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("APIKey is empty"))
+	}
+
+	if r.URL == "" {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("URL is empty"))
+	}
+
+	if len(r.Image) == 0 {
+		return errors.Join(ErrRequiredParam, fmt.Errorf("image is empty"))
+	}
+
+	if n := len(r.Image); n > MaxImageSize {
+		return errors.Join(ErrTextRecognition, fmt.Errorf("image is too large: %d bytes", n))
+	}
+
+	return nil
+}
+
+// marshal returns a reader with the request body.
+func (r *OCRRequest) marshal() (io.Reader, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	recognition := &TextRecognition{
+		MimeType:      r.MimeType,
+		LanguageCodes: r.Languages,
+		Model:         ModelPage,
+		Content:       r.Image,
+	}
+
+	if recognition.MimeType == "" {
+		recognition.MimeType = MimeTypeJPEG
+	}
+
+	if len(recognition.LanguageCodes) == 0 {
+		recognition.LanguageCodes = []string{"*"}
+	}
+
+	data, err := json.Marshal(recognition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+// build returns a new http.Request.
+func (r *OCRRequest) build(ctx context.Context) (*http.Request, error) {
+	data, err := r.marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Api-Key "+r.APIKey)
+
+	return req, nil
+}
+
+// RecognizeText returns a recognized text of the image.
+func RecognizeText(ctx context.Context, client *http.Client, req *OCRRequest) (*OCRResponse, error) {
+	request, err := req.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Join(ErrTextRecognition, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apierror.New(ErrTextRecognition, resp.StatusCode, resp.Body)
+	}
+
+	response := &OCRResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, errors.Join(ErrTextRecognition, err)
+	}
+
+	return response, nil
+}
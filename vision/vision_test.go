@@ -0,0 +1,113 @@
+package vision
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecognizeText(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Api-Key test-key" {
+			t.Errorf("failed authorization header: %q", auth)
+		}
+
+		request := &TextRecognition{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		if c := string(request.Content); c != "image" {
+			t.Errorf("unexpected content: %q", c)
+		}
+
+		if request.MimeType != MimeTypeJPEG || request.Model != ModelPage {
+			t.Errorf("unexpected request: %#v", request)
+		}
+
+		if l := request.LanguageCodes; len(l) != 1 || l[0] != "*" {
+			t.Errorf("unexpected languages: %v", l)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"result":{"textAnnotation":{"width":"100","height":"20","fullText":"panic: nil map"}}}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	req := &OCRRequest{APIKey: "test-key", URL: s.URL, Image: []byte("image")}
+
+	resp, err := RecognizeText(context.Background(), s.Client(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r := resp.String(); r != "panic: nil map" {
+		t.Errorf("unexpected result: %q", r)
+	}
+}
+
+func TestRecognizeTextFailed(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "test", http.StatusUnauthorized)
+	}))
+	defer s.Close()
+
+	req := &OCRRequest{APIKey: "test-key", URL: s.URL, Image: []byte("image")}
+
+	_, err := RecognizeText(context.Background(), s.Client(), req)
+	if !errors.Is(err, ErrTextRecognition) {
+		t.Fatalf("expected error: %v, got: %v", ErrTextRecognition, err)
+	}
+
+	expectedPrefix := "failed to recognize text\nunexpected status code"
+	if e := err.Error(); !strings.HasPrefix(e, expectedPrefix) {
+		t.Fatalf("expected %q, got %q", expectedPrefix, e)
+	}
+}
+
+func TestOCRRequestMarshal(t *testing.T) {
+	testCases := []struct {
+		name      string
+		req       OCRRequest
+		err       error
+		errSubStr string
+	}{
+		{name: "empty", req: OCRRequest{}, err: ErrRequiredParam, errSubStr: "APIKey is empty"},
+		{name: "noURL", req: OCRRequest{APIKey: "test-key"}, err: ErrRequiredParam, errSubStr: "URL is empty"},
+		{
+			name:      "noImage",
+			req:       OCRRequest{APIKey: "test-key", URL: OCRURL},
+			err:       ErrRequiredParam,
+			errSubStr: "image is empty",
+		},
+		{
+			name:      "large",
+			req:       OCRRequest{APIKey: "test-key", URL: OCRURL, Image: make([]byte, MaxImageSize+1)},
+			err:       ErrTextRecognition,
+			errSubStr: "image is too large",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.req.marshal()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error: %v, got: %v", tc.err, err)
+			}
+
+			if !strings.Contains(err.Error(), tc.errSubStr) {
+				t.Fatalf("expected error: %v, got: %v", tc.errSubStr, err)
+			}
+		})
+	}
+}
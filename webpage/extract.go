@@ -0,0 +1,147 @@
+package webpage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped are elements without readable text: scripts, navigation and other boilerplate.
+var skipped = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Template: true,
+	atom.Select:   true,
+}
+
+// blocks are elements which text starts from a new line.
+var blocks = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Div:        true,
+	atom.Br:         true,
+	atom.Li:         true,
+	atom.Tr:         true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Pre:        true,
+	atom.Blockquote: true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Main:       true,
+	atom.Table:      true,
+}
+
+// Extract returns a title and a readable text of HTML document.
+// The text of "article" or "main" element is preferred if it exists.
+func Extract(r io.Reader) (string, string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	var title string
+	if n := find(doc, atom.Title); n != nil {
+		title = strings.TrimSpace(nodeText(n))
+	}
+
+	root := find(doc, atom.Article)
+	if root == nil {
+		root = find(doc, atom.Main)
+	}
+
+	if root == nil {
+		if root = find(doc, atom.Body); root == nil {
+			root = doc
+		}
+	}
+
+	var b strings.Builder
+	writeText(&b, root)
+
+	return title, normalize(b.String()), nil
+}
+
+// find returns the first element node with the atom.
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := find(child, a); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// nodeText returns all text of the node.
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			b.WriteString(child.Data)
+		}
+	}
+
+	return b.String()
+}
+
+// writeText writes a readable text of the node, skipped elements are ignored.
+func writeText(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(n.Data)
+		return
+	case html.CommentNode:
+		return
+	case html.ElementNode:
+		if skipped[n.DataAtom] {
+			return
+		}
+	}
+
+	isBlock := n.Type == html.ElementNode && blocks[n.DataAtom]
+	if isBlock {
+		b.WriteString("\n")
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		writeText(b, child)
+	}
+
+	if isBlock {
+		b.WriteString("\n")
+	}
+}
+
+// normalize collapses spaces inside lines and removes empty lines.
+func normalize(text string) string {
+	var lines []string
+
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,180 @@
+package webpage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrRequiredParam is an error that occurs when a required parameter is missing.
+	ErrRequiredParam = errors.New("required parameter is missing")
+
+	// ErrFetch is an error that occurs when a page can not be fetched.
+	ErrFetch = errors.New("failed to fetch page")
+
+	// ErrDomain is an error that occurs when a page domain is not allowed.
+	ErrDomain = errors.New("domain is not allowed")
+
+	// ErrContentType is an error that occurs when a page content type is not supported.
+	ErrContentType = errors.New("unsupported content type")
+
+	// ErrTooLarge is an error that occurs when a page is too large.
+	ErrTooLarge = errors.New("page is too large")
+)
+
+// Page is a fetched web page.
+type Page struct {
+	URL       string
+	Title     string
+	Text      string
+	Truncated bool // the text is truncated to the maximum length
+}
+
+// Request is a request params structure to fetch a web page.
+type Request struct {
+	URL      string
+	MaxSize  int64    // maximum response body size in bytes
+	MaxChars int      // maximum text length in characters
+	Allow    []string // allowed domains, all are allowed if it is empty
+	Deny     []string // denied domains
+}
+
+func (r *Request) validate() (*url.URL, error) {
+	if r.URL == "" {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("URL is empty"))
+	}
+
+	if r.MaxSize <= 0 || r.MaxChars <= 0 {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("limits are not set"))
+	}
+
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, errors.Join(ErrFetch, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Join(ErrFetch, fmt.Errorf("unsupported URL scheme %q", u.Scheme))
+	}
+
+	return u, r.checkDomain(u)
+}
+
+// checkDomain returns an error if the URL host is denied or not allowed.
+func (r *Request) checkDomain(u *url.URL) error {
+	host := strings.ToLower(u.Hostname())
+
+	if matchDomain(host, r.Deny) {
+		return errors.Join(ErrDomain, fmt.Errorf("domain %q is denied", host))
+	}
+
+	if len(r.Allow) > 0 && !matchDomain(host, r.Allow) {
+		return errors.Join(ErrDomain, fmt.Errorf("domain %q is not in the allow list", host))
+	}
+
+	return nil
+}
+
+// matchDomain returns true if the host is one of domains or their subdomain.
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.Trim(domain, ". "))
+
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Fetch returns a web page with its readable text.
+// Domains of redirects are checked too.
+func Fetch(ctx context.Context, client *http.Client, req *Request) (*Page, error) {
+	u, err := req.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	c := *client
+	c.CheckRedirect = func(r *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+
+		return req.checkDomain(r.URL)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Join(ErrFetch, err)
+	}
+
+	request.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain")
+
+	resp, err := c.Do(request)
+	if err != nil {
+		return nil, errors.Join(ErrFetch, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Join(ErrFetch, fmt.Errorf("unexpected status code=%d", resp.StatusCode))
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, errors.Join(ErrContentType, err)
+	}
+
+	if resp.ContentLength > req.MaxSize {
+		return nil, errors.Join(ErrTooLarge, fmt.Errorf("size %d bytes", resp.ContentLength))
+	}
+
+	// read one more byte to check the limit if the content length is unknown
+	data, err := io.ReadAll(io.LimitReader(resp.Body, req.MaxSize+1))
+	if err != nil {
+		return nil, errors.Join(ErrFetch, err)
+	}
+
+	if int64(len(data)) > req.MaxSize {
+		return nil, errors.Join(ErrTooLarge, fmt.Errorf("maximum size is %d bytes", req.MaxSize))
+	}
+
+	page := &Page{URL: resp.Request.URL.String()}
+
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		page.Title, page.Text, err = Extract(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.Join(ErrFetch, err)
+		}
+	case "text/plain":
+		page.Text = strings.TrimSpace(string(data))
+	default:
+		return nil, errors.Join(ErrContentType, fmt.Errorf("content type %q", mediaType))
+	}
+
+	page.Text, page.Truncated = truncate(page.Text, req.MaxChars)
+	return page, nil
+}
+
+// truncate returns the text truncated to maxChars characters.
+func truncate(text string, maxChars int) (string, bool) {
+	if utf8.RuneCountInString(text) <= maxChars {
+		return text, false
+	}
+
+	return string([]rune(text)[:maxChars]), true
+}
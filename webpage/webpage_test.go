@@ -0,0 +1,149 @@
+package webpage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testPage = `<!DOCTYPE html>
+<html>
+<head><title> Test page </title><style>body {color: red;}</style></head>
+<body>
+<header>Site name</header>
+<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+<article>
+  <h1>Go 1.21   is released</h1>
+  <p>It has <b>new</b> built-in functions.</p>
+  <script>alert("x")</script>
+  <ul><li>min</li><li>max</li></ul>
+</article>
+<footer>Copyright</footer>
+</body>
+</html>`
+
+const testPageText = "Go 1.21 is released\nIt has new built-in functions.\nmin\nmax"
+
+func TestExtract(t *testing.T) {
+	title, text, err := Extract(strings.NewReader(testPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if title != "Test page" {
+		t.Errorf("unexpected title: %q", title)
+	}
+
+	if text != testPageText {
+		t.Errorf("unexpected text: %q", text)
+	}
+
+	_, text, err = Extract(strings.NewReader("<html><body><nav>menu</nav><p>one</p>two</body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text != "one\ntwo" {
+		t.Errorf("unexpected text: %q", text)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = fmt.Fprint(w, testPage)
+		case "/text":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = fmt.Fprint(w, "plain text is here")
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = fmt.Fprint(w, "png")
+		case "/large":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = fmt.Fprint(w, strings.Repeat("a", 2000))
+		case "/redirect":
+			http.Redirect(w, r, "http://example.com/", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	testCases := []struct {
+		name     string
+		req      Request
+		expected Page
+		err      error
+	}{
+		{
+			name:     "html",
+			req:      Request{URL: s.URL + "/page", MaxSize: 1000, MaxChars: 100},
+			expected: Page{URL: s.URL + "/page", Title: "Test page", Text: testPageText},
+		},
+		{
+			name:     "truncated",
+			req:      Request{URL: s.URL + "/text", MaxSize: 1000, MaxChars: 10, Allow: []string{"127.0.0.1"}},
+			expected: Page{URL: s.URL + "/text", Text: "plain text", Truncated: true},
+		},
+		{name: "image", req: Request{URL: s.URL + "/image", MaxSize: 1000, MaxChars: 10}, err: ErrContentType},
+		{name: "large", req: Request{URL: s.URL + "/large", MaxSize: 1000, MaxChars: 10}, err: ErrTooLarge},
+		{name: "notFound", req: Request{URL: s.URL + "/unknown", MaxSize: 1000, MaxChars: 10}, err: ErrFetch},
+		{name: "denied", req: Request{URL: s.URL, MaxSize: 1000, MaxChars: 10, Deny: []string{"127.0.0.1"}}, err: ErrDomain},
+		{
+			name: "notAllowed",
+			req:  Request{URL: s.URL, MaxSize: 1000, MaxChars: 10, Allow: []string{"example.com"}},
+			err:  ErrDomain,
+		},
+		{
+			name: "redirect",
+			req:  Request{URL: s.URL + "/redirect", MaxSize: 1000, MaxChars: 10, Deny: []string{"example.com"}},
+			err:  ErrDomain,
+		},
+		{name: "scheme", req: Request{URL: "ftp://127.0.0.1/", MaxSize: 1000, MaxChars: 10}, err: ErrFetch},
+		{name: "empty", req: Request{MaxSize: 1000, MaxChars: 10}, err: ErrRequiredParam},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := Fetch(context.Background(), s.Client(), &tc.req)
+			if err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected error: %v, got: %v", tc.err, err)
+				}
+				return
+			}
+
+			if tc.err != nil {
+				t.Fatalf("expected error, but got nil")
+			}
+
+			if *page != tc.expected {
+				t.Errorf("expected: %#v, got: %#v", tc.expected, page)
+			}
+		})
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	domains := []string{"example.com", ".golang.org"}
+
+	for host, expected := range map[string]bool{
+		"example.com":     true,
+		"www.example.com": true,
+		"badexample.com":  false,
+		"go.golang.org":   true,
+		"golang.org":      true,
+		"golang.org.ru":   false,
+	} {
+		if m := matchDomain(host, domains); m != expected {
+			t.Errorf("unexpected result for %q: %v", host, m)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package ygpt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/z0rr0/tgtpgybot/apierror"
+)
+
+// TokenizeURL is a text tokenization API URL.
+const TokenizeURL = "https://llm.api.cloud.yandex.net/llm/v1alpha/tokenize"
+
+// ErrTokenize is an error that occurs when a tokenization request fails.
+var ErrTokenize = errors.New("failed to tokenize")
+
+// Token is a text token of the model.
+type Token struct {
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	Special bool   `json:"special"`
+}
+
+// TokenizeResponse is a response from the tokenization API.
+type TokenizeResponse struct {
+	Tokens       []Token `json:"tokens"`
+	ModelVersion string  `json:"modelVersion"`
+}
+
+// TokenizeRequest is a request params structure for the tokenization API.
+type TokenizeRequest struct {
+	APIKey string
+	URL    string
+	Text   string
+}
+
+// tokenizeBody is a request body of the tokenization API.
+type tokenizeBody struct {
+	Model Model  `json:"model"`
+	Text  string `json:"text"`
+}
+
+// build returns a new http.Request.
+func (t *TokenizeRequest) build(ctx context.Context) (*http.Request, error) {
+	if t.APIKey == "" || t.URL == "" {
+		return nil, errors.Join(ErrRequiredParam, fmt.Errorf("APIKey or URL is empty"))
+	}
+
+	data, err := json.Marshal(&tokenizeBody{Model: ModelGeneral, Text: t.Text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Api-Key "+t.APIKey)
+
+	return req, nil
+}
+
+// Tokenize returns tokens of the text by the model tokenizer.
+func Tokenize(ctx context.Context, client *http.Client, req *TokenizeRequest) (*TokenizeResponse, error) {
+	request, err := req.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Join(ErrTokenize, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apierror.New(ErrTokenize, resp.StatusCode, resp.Body)
+	}
+
+	response := &TokenizeResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, errors.Join(ErrTokenize, err)
+	}
+
+	return response, nil
+}
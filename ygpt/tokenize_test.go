@@ -0,0 +1,72 @@
+package ygpt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/z0rr0/tgtpgybot/apierror"
+)
+
+func TestTokenize(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Api-Key test-key" {
+			t.Errorf("failed authorization header: %q", auth)
+		}
+
+		body := make(map[string]string)
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+
+		if body["model"] != "general" || body["text"] != "Кто ты?" {
+			t.Errorf("unexpected request: %v", body)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := `{"tokens":[{"id":"1","text":"Кто","special":false},{"id":"2","text":" ты","special":false},` +
+			`{"id":"3","text":"?","special":false}],"modelVersion":"18.01.2024"}`
+
+		if _, err := fmt.Fprint(w, response); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	req := &TokenizeRequest{APIKey: "test-key", URL: s.URL, Text: "Кто ты?"}
+
+	resp, err := Tokenize(context.Background(), s.Client(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(resp.Tokens); n != 3 || resp.Tokens[1].Text != " ты" {
+		t.Errorf("unexpected tokens: %v", resp.Tokens)
+	}
+}
+
+func TestTokenizeFailed(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer s.Close()
+
+	_, err := Tokenize(context.Background(), s.Client(), &TokenizeRequest{APIKey: "test-key", URL: s.URL, Text: "test"})
+	if !errors.Is(err, ErrTokenize) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	var statusErr *apierror.StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusTooManyRequests {
+		t.Errorf("unexpected status error: %v", err)
+	}
+
+	_, err = Tokenize(context.Background(), s.Client(), &TokenizeRequest{URL: s.URL})
+	if !errors.Is(err, ErrRequiredParam) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -9,10 +9,20 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+
+	"github.com/z0rr0/tgtpgybot/apierror"
 )
 
-// ChatURL is a chat generation API URL.
-const ChatURL = "https://llm.api.cloud.yandex.net/llm/v1alpha/chat"
+const (
+	// ChatURL is a chat generation API URL.
+	ChatURL = "https://llm.api.cloud.yandex.net/llm/v1alpha/chat"
+
+	// DefaultMaxTokens is a default maximum number of tokens in the generated answer.
+	DefaultMaxTokens = 2000
+
+	// DefaultContextTokens is a default maximum number of tokens in the request and its answer.
+	DefaultContextTokens = 8000
+)
 
 var (
 	// ErrRequiredParam is an error that occurs when a required parameter is missing.
@@ -73,9 +83,22 @@ func (cr *ChatResponse) parseNumTokens() error {
 
 // ChatRequest is a request params structure for the chat generation API.
 type ChatRequest struct {
-	APIKey string
-	URL    string
-	Text   string
+	APIKey      string
+	URL         string
+	Text        string
+	Instruction string    // optional instruction text
+	Messages    []Message // optional previous messages before the text
+	Options     GenerationOptions
+}
+
+// GenerationOptions returns the request generation options, empty values are replaced by defaults.
+func (c *ChatRequest) GenerationOptions() GenerationOptions {
+	options := c.Options
+	if options.MaxTokens <= 0 {
+		options.MaxTokens = DefaultMaxTokens
+	}
+
+	return options
 }
 
 func (c *ChatRequest) validate() error {
@@ -101,11 +124,16 @@ func (c *ChatRequest) marshal() (io.Reader, error) {
 		return nil, err
 	}
 
-	// YandexGPT API is preview, so use only "general" model, Temperature=0 and MaxTokens=2000.
+	// YandexGPT API is preview, so use only "general" model.
+	messages := make([]Message, 0, len(c.Messages)+1)
+	messages = append(messages, c.Messages...)
+	messages = append(messages, Message{Role: RoleUser, Text: c.Text})
+
 	chatData := &TextGenerationChat{
 		Model:             ModelGeneral,
-		GenerationOptions: GenerationOptions{MaxTokens: 2000},
-		Messages:          []Message{{Role: RoleUser, Text: c.Text}},
+		GenerationOptions: c.GenerationOptions(),
+		Messages:          messages,
+		InstructionText:   c.Instruction,
 	}
 
 	data, err := json.Marshal(chatData)
@@ -152,28 +180,12 @@ func GenerationChat(ctx context.Context, client *http.Client, req *ChatRequest)
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, statusError(resp.StatusCode, resp.Body)
+		return nil, apierror.New(ErrChatGeneration, resp.StatusCode, resp.Body)
 	}
 
 	return buildResponse(resp.Body)
 }
 
-func statusError(status int, body io.Reader) error {
-	bodyBytes, err := io.ReadAll(body)
-	if err != nil {
-		return errors.Join(
-			ErrChatGeneration,
-			fmt.Errorf("unexpected status code=%d", status),
-			err,
-		)
-	}
-
-	return errors.Join(
-		ErrChatGeneration,
-		fmt.Errorf("unexpected status code=%v: %v", status, string(bodyBytes)),
-	)
-}
-
 func buildResponse(reader io.Reader) (*ChatResponse, error) {
 	response := &ChatResponse{}
 	if err := json.NewDecoder(reader).Decode(response); err != nil {
@@ -9,6 +9,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/z0rr0/tgtpgybot/apierror"
 )
 
 func TestGenerationChat(t *testing.T) {
@@ -101,6 +103,15 @@ func TestGenerationChatFailedStatus(t *testing.T) {
 	if e := err.Error(); !strings.HasPrefix(e, expectedPrefix) {
 		t.Fatalf("expected %q, got %q", expectedPrefix, e)
 	}
+
+	var statusErr *apierror.StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected status error, got %T", err)
+	}
+
+	if statusErr.Code != http.StatusBadGateway || statusErr.Body != "test\n" {
+		t.Errorf("unexpected status error: %+v", statusErr)
+	}
 }
 
 func TestGenerationChatMarshal(t *testing.T) {
@@ -149,6 +160,33 @@ func TestGenerationChatMarshal(t *testing.T) {
 				`"text":"test"`,
 			},
 		},
+		{
+			name: "instruction",
+			req: ChatRequest{
+				APIKey:      "test-key",
+				URL:         ChatURL,
+				Text:        "test",
+				Instruction: "be brief",
+				Messages:    []Message{{Role: RoleUser, Text: "hi"}, {Role: RoleAssistant, Text: "hello"}},
+			},
+			expected: []string{
+				`"messages":[{"role":"User","text":"hi"},{"role":"Assistant","text":"hello"},{"role":"User","text":"test"}]`,
+				`"instructionText":"be brief"`,
+			},
+		},
+		{
+			name: "options",
+			req: ChatRequest{
+				APIKey:  "test-key",
+				URL:     ChatURL,
+				Text:    "test",
+				Options: GenerationOptions{Temperature: 0.5, MaxTokens: 100},
+			},
+			expected: []string{
+				`"temperature":0.5`,
+				`"maxTokens":100`,
+			},
+		},
 	}
 
 	for i := range testCases {